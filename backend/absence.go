@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DefaultGraceSeconds = 180
+	MaxGraceSeconds     = 1800
+)
+
+const (
+	AbsenceSubstitute = "substitute"
+	AbsenceBot        = "bot"
+	AbsenceAbort      = "abort"
+)
+
+// Absence reserves the side of the player who left during a pulka at Since.
+// The game is paused until the player returns, after Until the others vote
+// on how to go on.
+type Absence struct {
+	Player string        `json:"player" bson:"player"`
+	Since  time.Time     `json:"since" bson:"since"`
+	Until  time.Time     `json:"until" bson:"until"`
+	Votes  []AbsenceVote `json:"votes" bson:"votes"`
+}
+
+type AbsenceVote struct {
+	Player     string `json:"player" bson:"player"`
+	Option     string `json:"option" bson:"option"`
+	Substitute string `json:"substitute,omitempty" bson:"substitute,omitempty"`
+}
+
+func (r *Room) GracePeriod() time.Duration {
+	seconds := DefaultGraceSeconds
+	if r.Settings != nil && r.Settings.GraceSeconds > 0 {
+		seconds = r.Settings.GraceSeconds
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// voters are the human players waiting for the absent one.
+func (r *Room) voters() []string {
+	var res []string
+	for _, side := range r.Sides {
+		if side.Name == EMPTY_SIDE || side.Bot != "" || (r.Away != nil && side.Name == r.Away.Player) {
+			continue
+		}
+		res = append(res, side.Name)
+	}
+
+	return res
+}
+
+// CanReserve tells whether the leaving player keeps the side: during a
+// pulka of a ruled room with somebody to wait for the player.
+func (r *Room) CanReserve(playerName string) bool {
+	if r.Game == nil || r.Puzzle != nil || r.Away != nil || !r.PulkaStarted() {
+		return false
+	}
+
+	for _, name := range r.voters() {
+		if name != playerName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Room) Reserve(playerName string, now time.Time) {
+	r.Away = &Absence{
+		Player: playerName,
+		Since:  now,
+		Until:  now.Add(r.GracePeriod()),
+		Votes:  []AbsenceVote{},
+	}
+}
+
+// Return resumes the game paused for the player, the time spent away is not
+// counted against the clock.
+func (r *Room) Return(playerName string, now time.Time) bool {
+	if r.Away == nil || r.Away.Player != playerName {
+		return false
+	}
+
+	r.Game.DecisionSince = r.Game.DecisionSince.Add(now.Sub(r.Away.Since))
+	r.Away = nil
+
+	return true
+}
+
+// VoteAbsence records the vote and carries out the option once the majority
+// of the voters agrees on it.
+func (r *Room) VoteAbsence(vote AbsenceVote, now time.Time) (bool, error) {
+	if r.Away == nil {
+		return false, errors.New("nobody is away")
+	}
+
+	if now.Before(r.Away.Until) {
+		return false, errors.New("grace period is not over")
+	}
+
+	voters := r.voters()
+	if !containsName(voters, vote.Player) {
+		return false, errors.New("player cannot vote")
+	}
+
+	switch vote.Option {
+	case AbsenceSubstitute:
+		if vote.Substitute == "" || vote.Substitute == EMPTY_SIDE || r.PlayerSideIndex(vote.Substitute) != -1 {
+			return false, errors.New("wrong substitute")
+		}
+	case AbsenceBot, AbsenceAbort:
+		vote.Substitute = ""
+	default:
+		return false, errors.New("unknown option")
+	}
+
+	votes := []AbsenceVote{vote}
+	agreed := 1
+	for _, v := range r.Away.Votes {
+		if v.Player == vote.Player {
+			continue
+		}
+		votes = append(votes, v)
+		if v.Option == vote.Option && v.Substitute == vote.Substitute {
+			agreed++
+		}
+	}
+	r.Away.Votes = votes
+
+	if 2*agreed <= len(voters) {
+		return false, nil
+	}
+
+	index := r.PlayerSideIndex(r.Away.Player)
+	switch vote.Option {
+	case AbsenceSubstitute:
+		r.replacePlayer(index, vote.Substitute, "")
+	case AbsenceBot:
+		r.replacePlayer(index, BotName(r.ID, index), "heuristic")
+	case AbsenceAbort:
+		r.abortPulka(index)
+		return true, nil
+	}
+	r.Away = nil
+	r.Game.DecisionSince = now
+
+	return true, nil
+}
+
+// replacePlayer seats the substitute at the side in the middle of the game,
+// the deal, the score sheet and the event log go on under the new name.
+func (r *Room) replacePlayer(index int, name, bot string) {
+	old := r.Sides[index].Name
+	rename := func(player *string) {
+		if *player == old {
+			*player = name
+		}
+	}
+
+	r.Sides[index].Name = name
+	r.Sides[index].Bot = bot
+	r.Hints = removeName(r.Hints, old)
+	for i := range r.Center {
+		rename(&r.Center[i].Player)
+	}
+	for i := range r.LastTrick {
+		rename(&r.LastTrick[i].Player)
+	}
+	for i := range r.Game.Bids {
+		rename(&r.Game.Bids[i].Player)
+	}
+	for i := range r.Game.Whists {
+		rename(&r.Game.Whists[i].Player)
+	}
+	for i := range r.Game.Plays {
+		rename(&r.Game.Plays[i].Player)
+	}
+	if r.Score != nil {
+		for i := range r.Score.Players {
+			rename(&r.Score.Players[i].Player)
+		}
+	}
+	if r.Duplicate != nil {
+		for i := range r.Duplicate.Players {
+			rename(&r.Duplicate.Players[i])
+		}
+	}
+	for i := range r.Events {
+		rename(&r.Events[i].Player)
+		if deal := r.Events[i].Deal; deal != nil && deal.Sides[index].Name == old {
+			deal.Sides[index].Name = name
+			deal.Sides[index].Bot = bot
+		}
+	}
+}
+
+// leave frees the side of the leaving player. The pulka paused for another
+// absent player is aborted, so the score sheet is kept.
+func (r *Room) leave(index int) {
+	if r.Away != nil && r.PulkaStarted() {
+		r.abortPulka(index)
+		return
+	}
+
+	r.removePlayer(index)
+}
+
+// abortPulka frees the side of the absent player and finishes the pulka
+// with the score sheet as it is, the unfinished deal is not archived.
+func (r *Room) abortPulka(index int) {
+	r.Away = nil
+	r.Game.Finished = true
+	r.Game.Archived = true
+	if r.Score != nil {
+		r.Score.Finished = true
+		r.Score.Aborted = true
+	}
+	r.vacate(index)
+	r.Status = RoomStatusCreated
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
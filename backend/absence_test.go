@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAbsenceRoom(t *testing.T) *Room {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	room.Score = NewScoreSheet([]string{"evgsol", "solarka", "psmirnov"}, ConventionSochi, 10)
+	require.NoError(t, room.Shuffle("evgsol"))
+	require.True(t, room.CanReserve("solarka"))
+
+	return room
+}
+
+func TestReserveSeat(t *testing.T) {
+	now := time.Now()
+	room := newAbsenceRoom(t)
+	cards := append([]Card{}, room.Sides[1].Cards...)
+
+	room.Reserve("solarka", now)
+	_, kind := room.Decision()
+	assert.Equal(t, DecisionNone, kind)
+	assert.Error(t, room.Apply("evgsol", DecisionBid, Action{Bid: BidPass}))
+	_, err := room.VoteAbsence(AbsenceVote{Player: "evgsol", Option: AbsenceBot}, now)
+	assert.Error(t, err)
+
+	thinking := now.Sub(room.Game.DecisionSince)
+	assert.False(t, room.Return("evgsol", now.Add(time.Minute)))
+	assert.True(t, room.Return("solarka", now.Add(time.Minute)))
+	assert.Equal(t, thinking, now.Add(time.Minute).Sub(room.Game.DecisionSince))
+	assert.Equal(t, cards, room.Sides[1].Cards)
+	_, kind = room.Decision()
+	assert.NotEqual(t, DecisionNone, kind)
+}
+
+func TestVoteAbsence(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+
+	room := newAbsenceRoom(t)
+	room.Reserve("solarka", time.Now())
+	_, err := room.VoteAbsence(AbsenceVote{Player: "solarka", Option: AbsenceBot}, later)
+	assert.Error(t, err)
+	done, err := room.VoteAbsence(AbsenceVote{Player: "evgsol", Option: AbsenceSubstitute, Substitute: "kek"}, later)
+	require.NoError(t, err)
+	assert.False(t, done)
+	done, err = room.VoteAbsence(AbsenceVote{Player: "psmirnov", Option: AbsenceSubstitute, Substitute: "kek"}, later)
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.Nil(t, room.Away)
+	assert.Equal(t, "kek", room.Sides[1].Name)
+	assert.NotEqual(t, -1, room.Score.index("kek"))
+
+	room = newAbsenceRoom(t)
+	room.Reserve("solarka", time.Now())
+	_, err = room.VoteAbsence(AbsenceVote{Player: "evgsol", Option: AbsenceAbort}, later)
+	require.NoError(t, err)
+	_, err = room.VoteAbsence(AbsenceVote{Player: "psmirnov", Option: AbsenceAbort}, later)
+	require.NoError(t, err)
+	assert.Equal(t, EMPTY_SIDE, room.Sides[1].Name)
+	assert.Equal(t, RoomStatusCreated, room.Status)
+	assert.True(t, room.Score.Finished && room.Score.Aborted)
+	assert.False(t, room.PulkaStarted())
+
+	room = newAbsenceRoom(t)
+	room.Reserve("solarka", time.Now())
+	require.False(t, room.CanReserve("evgsol"))
+	room.leave(0)
+	assert.Equal(t, EMPTY_SIDE, room.Sides[0].Name)
+	assert.NotNil(t, room.Game)
+	assert.True(t, room.Score.Finished && room.Score.Aborted)
+	assert.Nil(t, room.Away)
+}
+
+func TestKibitzAfterSubstitution(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+	strategy := &HeuristicStrategy{}
+
+	room := newAbsenceRoom(t)
+	for i := 0; i < 3; i++ {
+		index, kind := room.Decision()
+		require.NoError(t, room.Apply(room.Sides[index].Name, kind, strategy.Decide(room.ViewFor(index))))
+	}
+
+	room.Reserve("solarka", time.Now())
+	for _, name := range []string{"evgsol", "psmirnov"} {
+		_, err := room.VoteAbsence(AbsenceVote{Player: name, Option: AbsenceSubstitute, Substitute: "kek"}, later)
+		require.NoError(t, err)
+	}
+	require.Equal(t, "kek", room.Sides[1].Name)
+
+	for i := 0; i < 3; i++ {
+		index, kind := room.Decision()
+		require.NoError(t, room.Apply(room.Sides[index].Name, kind, strategy.Decide(room.ViewFor(index))))
+	}
+
+	room.KibitzDelay = &KibitzDelay{Seconds: 60}
+	view, err := room.KibitzView(later)
+	require.NoError(t, err)
+	require.NotNil(t, view.Room)
+	assert.Equal(t, "kek", view.Room.Sides[1].Name)
+	assert.Equal(t, room.Status, view.Room.Status)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Arena plays pulkas between strategies in memory with the rules of the
+// rooms. With Duplicate every set of deals is replayed with the strategies
+// rotated through all the seats.
+type Arena struct {
+	Strategies []string
+	PulkaSize  int
+	Convention Convention
+	Seed       int64
+	Duplicate  bool
+	MaxDeals   int
+}
+
+type ArenaStats struct {
+	Strategy string  `json:"strategy"`
+	Samples  int     `json:"samples"`
+	Mean     float64 `json:"mean"`
+	StdDev   float64 `json:"stdDev"`
+	Low      float64 `json:"low"`
+	High     float64 `json:"high"`
+}
+
+// ArenaDealSeed gives the same cards to the deal of the same set whoever
+// sits at the table.
+func ArenaDealSeed(seed int64, set, deal int) *DealSeed {
+	serverSeed := fmt.Sprintf("arena-%d-%d-%d", seed, set, deal)
+	return &DealSeed{
+		ServerSeed: serverSeed,
+		Commitment: SeedCommitment(serverSeed),
+		Entropy:    map[string]string{},
+	}
+}
+
+// PlayPulka plays a pulka of the set with the strategies seated in order
+// and returns their balances.
+func (a *Arena) PlayPulka(set int, seating []string) ([]float64, error) {
+	if len(seating) < 3 || len(seating) > 4 {
+		return nil, errors.New("wrong players count")
+	}
+
+	room := &Room{
+		Sides:  make([]RoomSideInfo, 4),
+		Status: RoomStatusReady,
+	}
+	players := make([]Strategy, 4)
+	var names []string
+	for i, name := range seating {
+		newStrategy, ok := Strategies[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown strategy %q", name)
+		}
+		room.Sides[i].Name = fmt.Sprintf("%d-%s", i, name)
+		room.Sides[i].Bot = name
+		room.PlayersCount++
+		players[i] = newStrategy(a.Seed*1000003 + int64(set)*4 + int64(i))
+		names = append(names, room.Sides[i].Name)
+	}
+
+	room.Score = NewScoreSheet(names, a.Convention, a.PulkaSize)
+	room.NextSeed = ArenaDealSeed(a.Seed, set, 0)
+	if err := room.Shuffle(names[0]); err != nil {
+		return nil, err
+	}
+
+	for {
+		index, kind := room.Decision()
+		if kind == DecisionNone {
+			break
+		}
+		if kind == DecisionShuffle {
+			if a.MaxDeals > 0 && room.Score.Deals >= a.MaxDeals {
+				break
+			}
+			room.NextSeed = ArenaDealSeed(a.Seed, set, room.Score.Deals)
+		}
+
+		action := players[index].Decide(room.ViewFor(index))
+		if err := room.Apply(room.Sides[index].Name, kind, action); err != nil {
+			return nil, fmt.Errorf("%s of %s: %w", kind, seating[index], err)
+		}
+	}
+
+	return room.Score.Balances(), nil
+}
+
+// Run plays the sets and returns the average balance of every strategy per
+// set with the 95% confidence interval.
+func (a *Arena) Run(sets int) ([]ArenaStats, error) {
+	if !a.Convention.Valid() {
+		return nil, errors.New("unknown convention")
+	}
+
+	rotations := 1
+	if a.Duplicate {
+		rotations = len(a.Strategies)
+	}
+
+	samples := map[string][]float64{}
+	for set := 0; set < sets; set++ {
+		sums := map[string]float64{}
+		counts := map[string]int{}
+		for r := 0; r < rotations; r++ {
+			seating := make([]string, len(a.Strategies))
+			for i := range seating {
+				seating[i] = a.Strategies[(i+r)%len(a.Strategies)]
+			}
+
+			balances, err := a.PlayPulka(set, seating)
+			if err != nil {
+				return nil, err
+			}
+			for i, name := range seating {
+				sums[name] += balances[i]
+				counts[name]++
+			}
+		}
+
+		for name, sum := range sums {
+			samples[name] = append(samples[name], sum/float64(counts[name]))
+		}
+	}
+
+	var res []ArenaStats
+	for name, values := range samples {
+		res = append(res, NewArenaStats(name, values))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Mean > res[j].Mean
+	})
+
+	return res, nil
+}
+
+func NewArenaStats(strategy string, values []float64) ArenaStats {
+	res := ArenaStats{
+		Strategy: strategy,
+		Samples:  len(values),
+	}
+
+	for _, v := range values {
+		res.Mean += v
+	}
+	res.Mean /= float64(len(values))
+
+	if len(values) > 1 {
+		for _, v := range values {
+			res.StdDev += (v - res.Mean) * (v - res.Mean)
+		}
+		res.StdDev = math.Sqrt(res.StdDev / float64(len(values)-1))
+	}
+
+	margin := 1.96 * res.StdDev / math.Sqrt(float64(len(values)))
+	res.Low = res.Mean - margin
+	res.High = res.Mean + margin
+
+	return res
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArenaIsReproducible(t *testing.T) {
+	arena := &Arena{
+		Strategies: []string{"heuristic", "random", "heuristic", "random"},
+		PulkaSize:  10,
+		Convention: ConventionSochi,
+		Seed:       7,
+		Duplicate:  true,
+		MaxDeals:   100,
+	}
+
+	first, err := arena.Run(2)
+	require.NoError(t, err)
+	second, err := arena.Run(2)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	require.Len(t, first, 2)
+	assert.Equal(t, 2, first[0].Samples)
+	assert.InDelta(t, 0, first[0].Mean+first[1].Mean, 1e-9)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	MaxBotActions = 100
+	BotNamePrefix = "bot-"
+
+	// ExternalBot marks sides of bot accounts playing through the API,
+	// FallbackStrategy acts for them when they are out of time.
+	ExternalBot      = "external"
+	FallbackStrategy = "heuristic"
+)
+
+// BotView is what a side is allowed to know when it has to act.
+type BotView struct {
+	Kind       DecisionKind `json:"kind"`
+	Side       int          `json:"side"`
+	Hand       []Card       `json:"hand"`
+	Room       Room         `json:"room"`
+	LegalBids  []Bid        `json:"legalBids,omitempty"`
+	LegalMoves []int        `json:"legalMoves,omitempty"`
+}
+
+type Action struct {
+	Bid     Bid   `json:"bid,omitempty"`
+	Whist   bool  `json:"whist,omitempty"`
+	Indexes []int `json:"indexes,omitempty"`
+	Index   int   `json:"index"`
+}
+
+func BotName(roomID RoomID, sideIndex int) string {
+	return fmt.Sprintf("%s%s-%d", BotNamePrefix, roomID.String(), sideIndex)
+}
+
+// ViewFor returns the decision of the side with the cards it cannot see
+// hidden, the room itself is not changed.
+func (r *Room) ViewFor(sideIndex int) BotView {
+	index, kind := r.Decision()
+	if index != sideIndex {
+		kind = DecisionNone
+	}
+
+	masked := *r
+	masked.Sides = append([]RoomSideInfo{}, r.Sides...)
+	masked.HideCards(r.Sides[sideIndex].Name)
+
+	view := BotView{
+		Kind: kind,
+		Side: sideIndex,
+		Hand: r.Sides[sideIndex].Cards,
+		Room: masked,
+	}
+
+	switch kind {
+	case DecisionBid:
+		view.LegalBids = r.LegalBids(sideIndex)
+	case DecisionDeclare:
+		view.LegalBids = r.LegalContracts()
+	case DecisionMove:
+		view.LegalMoves = r.LegalMoves(sideIndex)
+	}
+
+	return view
+}
+
+// BotDriver makes the moves of the bots seated in a room until a human has
+// to act.
+type BotDriver struct {
+	roomManager *RoomManager
+}
+
+func NewBotDriver(roomManager *RoomManager) *BotDriver {
+	return &BotDriver{
+		roomManager: roomManager,
+	}
+}
+
+func (d *BotDriver) Play(ctx context.Context, roomID RoomID) error {
+	for i := 0; i < MaxBotActions; i++ {
+		room, err := d.roomManager.GetOne(ctx, roomID)
+		if err != nil {
+			return err
+		}
+
+		index, kind := room.Decision()
+		if kind == DecisionNone || index < 0 {
+			return nil
+		}
+		switch room.Sides[index].Bot {
+		case ExternalBot:
+			return nil
+		case "":
+			// Humans only move by the queued and the forced moves.
+			played, err := d.roomManager.PlayQueued(ctx, roomID)
+			if err != nil || !played {
+				return err
+			}
+			continue
+		}
+
+		newStrategy, ok := Strategies[room.Sides[index].Bot]
+		if !ok {
+			return errors.New("unknown strategy")
+		}
+
+		action := newStrategy(time.Now().UnixNano()).Decide(room.ViewFor(index))
+		if err := d.roomManager.Act(ctx, roomID, room.Sides[index].Name, kind, action); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExpireExternal acts for the external bots which have been thinking since
+// before the deadline. A failure in one room does not hold up the others.
+func (d *BotDriver) ExpireExternal(ctx context.Context, deadline time.Time) error {
+	rooms, err := d.roomManager.GetAllWithGame(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, room := range rooms {
+		if err := d.expireExternal(ctx, &room, deadline); err != nil {
+			log.Println(err)
+		}
+	}
+
+	return nil
+}
+
+func (d *BotDriver) expireExternal(ctx context.Context, room *Room, deadline time.Time) error {
+	index, kind := room.Decision()
+	if kind == DecisionNone || index < 0 || room.Sides[index].Bot != ExternalBot {
+		return nil
+	}
+	if !room.Game.DecisionSince.Before(deadline) {
+		return nil
+	}
+
+	action := Strategies[FallbackStrategy](time.Now().UnixNano()).Decide(room.ViewFor(index))
+	if err := d.roomManager.Act(ctx, room.ID, room.Sides[index].Name, kind, action); err != nil {
+		return err
+	}
+
+	return d.Play(ctx, room.ID)
+}
+
+// ExpireClocks makes the expire action for the players out of time. A
+// failure in one room does not hold up the others.
+func (d *BotDriver) ExpireClocks(ctx context.Context, now time.Time) error {
+	rooms, err := d.roomManager.GetAllWithGame(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, room := range rooms {
+		if err := d.expireClock(ctx, &room, now); err != nil {
+			log.Println(err)
+		}
+	}
+
+	return nil
+}
+
+func (d *BotDriver) expireClock(ctx context.Context, room *Room, now time.Time) error {
+	index, left, ok := room.TimeLeft(now)
+	if !ok || left > 0 {
+		return nil
+	}
+
+	name := room.Sides[index].Name
+	since := room.Game.DecisionSince
+	if room.TimeControl().OnExpire == ExpireForfeit {
+		return d.roomManager.Forfeit(ctx, room.ID, since, name)
+	}
+
+	_, kind := room.Decision()
+	if err := d.roomManager.Expire(ctx, room.ID, since, name, kind, room.ExpireAction(index, kind)); err != nil {
+		return err
+	}
+
+	return d.Play(ctx, room.ID)
+}
+
+// ExpireProposals closes the proposals nobody has rejected in time. A
+// failure in one room does not hold up the others.
+func (d *BotDriver) ExpireProposals(ctx context.Context, now time.Time) error {
+	rooms, err := d.roomManager.GetAllWithExpiredProposal(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, room := range rooms {
+		if err := d.roomManager.ExpireProposal(ctx, &room, now); err != nil {
+			log.Println(err)
+		}
+	}
+
+	return nil
+}
+
+func (d *BotDriver) Watch(ctx context.Context, interval, timeLimit time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := d.ExpireExternal(ctx, now.Add(-timeLimit)); err != nil {
+				log.Println(err)
+			}
+			if err := d.ExpireClocks(ctx, now); err != nil {
+				log.Println(err)
+			}
+			if err := d.ExpireProposals(ctx, now); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
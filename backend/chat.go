@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/globalsign/mgo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	ChatCollectionName = "messages"
+	MaxMessageLength   = 500
+	MaxChatPage        = 50
+	// ChatInView is the number of the last messages sent with the room.
+	ChatInView = 20
+)
+
+// The players and the spectators talk in different channels, the players do
+// not read the spectators to avoid table talk.
+const (
+	ChannelPlayers    = "players"
+	ChannelSpectators = "spectators"
+)
+
+type MessageID = RoomID
+
+type ChatMessage struct {
+	ID      MessageID `json:"id" bson:"_id"`
+	RoomID  RoomID    `json:"roomId" bson:"roomId"`
+	Channel string    `json:"channel" bson:"channel"`
+	Author  string    `json:"author" bson:"author"`
+	Text    string    `json:"text" bson:"text"`
+	Time    time.Time `json:"time" bson:"time"`
+}
+
+// ChatChannels returns the channel the user writes to and the ones the user
+// reads in the room.
+func (r *Room) ChatChannels(name string) (string, []string, error) {
+	if name != EMPTY_SIDE && r.PlayerSideIndex(name) != -1 {
+		return ChannelPlayers, []string{ChannelPlayers}, nil
+	}
+
+	if containsName(r.Spectators, name) || r.IsKibitzer(name) {
+		return ChannelSpectators, []string{ChannelPlayers, ChannelSpectators}, nil
+	}
+
+	return "", nil, errors.New("user is not in the room")
+}
+
+// ChatFilter masks the parts of the messages matching any of the patterns.
+type ChatFilter struct {
+	patterns []*regexp.Regexp
+}
+
+func NewChatFilter(patterns []string) (*ChatFilter, error) {
+	res := &ChatFilter{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		res.patterns = append(res.patterns, re)
+	}
+
+	return res, nil
+}
+
+func (f *ChatFilter) Apply(text string) string {
+	if f == nil {
+		return text
+	}
+
+	for _, re := range f.patterns {
+		text = re.ReplaceAllStringFunc(text, func(s string) string {
+			return strings.Repeat("*", utf8.RuneCountInString(s))
+		})
+	}
+
+	return text
+}
+
+type ChatDAO struct {
+	collection *mgo.Collection
+}
+
+func NewChatDAO(session *mgo.Session) *ChatDAO {
+	return &ChatDAO{
+		collection: session.DB(RoomDatabaseName).C(ChatCollectionName),
+	}
+}
+
+// FindByRoom returns the last messages of the channels before the given one
+// in the order they were written, the zero ID starts from the latest.
+func (d *ChatDAO) FindByRoom(ctx context.Context, roomID RoomID, channels []string, before MessageID, limit int) ([]ChatMessage, error) {
+	query := bson.M{
+		"roomId":  roomID,
+		"channel": bson.M{"$in": channels},
+	}
+	if !before.IsZero() {
+		query["_id"] = bson.M{"$lt": before}
+	}
+
+	var result []ChatMessage
+	if err := d.collection.Find(query).Sort("-_id").Limit(limit).All(&result); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return result, nil
+}
+
+func (d *ChatDAO) Insert(ctx context.Context, message *ChatMessage) error {
+	return d.collection.Insert(message)
+}
+
+func (d *ChatDAO) RemoveAll(ctx context.Context) error {
+	_, err := d.collection.RemoveAll(bson.M{})
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatFilter(t *testing.T) {
+	filter, err := NewChatFilter([]string{`(?i)\bdamn\b`, "блин"})
+	require.NoError(t, err)
+	assert.Equal(t, "**** it, ****!", filter.Apply("Damn it, блин!"))
+	assert.Equal(t, "damnation", filter.Apply("damnation"))
+
+	var none *ChatFilter
+	assert.Equal(t, "damn", none.Apply("damn"))
+
+	_, err = NewChatFilter([]string{"("})
+	assert.Error(t, err)
+}
+
+func TestChatChannels(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	room.Spectators = []string{"kek"}
+
+	write, read, err := room.ChatChannels("evgsol")
+	require.NoError(t, err)
+	assert.Equal(t, ChannelPlayers, write)
+	assert.Equal(t, []string{ChannelPlayers}, read)
+
+	write, read, err = room.ChatChannels("kek")
+	require.NoError(t, err)
+	assert.Equal(t, ChannelSpectators, write)
+	assert.Equal(t, []string{ChannelPlayers, ChannelSpectators}, read)
+
+	_, _, err = room.ChatChannels(EMPTY_SIDE)
+	assert.Error(t, err)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	MaxIncrementSeconds = 120
+	MaxBankSeconds      = 3600
+)
+
+const (
+	ExpireAuto    = "auto"
+	ExpireForfeit = "forfeit"
+)
+
+// TimeControl gives every decision Increment seconds for free, the time over
+// it is taken from the player's bank for the pulka. When the bank is over the
+// expire action is made for the player: a pass, the lowest legal card or the
+// forfeit of the pulka.
+type TimeControl struct {
+	Increment int    `json:"increment" bson:"increment"`
+	Bank      int    `json:"bank" bson:"bank"`
+	OnExpire  string `json:"onExpire" bson:"onExpire"`
+}
+
+func (tc *TimeControl) Equal(other *TimeControl) bool {
+	if tc == nil || other == nil {
+		return tc == other
+	}
+
+	return *tc == *other
+}
+
+func (tc *TimeControl) Valid() bool {
+	if tc.Increment < 0 || tc.Increment > MaxIncrementSeconds || tc.Bank < 0 || tc.Bank > MaxBankSeconds {
+		return false
+	}
+	if tc.OnExpire != ExpireAuto && tc.OnExpire != ExpireForfeit {
+		return false
+	}
+
+	return tc.Increment > 0 || tc.Bank > 0
+}
+
+// ClockView shows the time left to the side deciding now, in milliseconds.
+type ClockView struct {
+	Side int `json:"side"`
+	Left int `json:"left"`
+}
+
+func (r *Room) ClockView(now time.Time) *ClockView {
+	index, left, ok := r.TimeLeft(now)
+	if !ok {
+		return nil
+	}
+
+	return &ClockView{Side: index, Left: int(left / time.Millisecond)}
+}
+
+func (r *Room) TimeControl() *TimeControl {
+	if r.Settings == nil {
+		return nil
+	}
+
+	return r.Settings.TimeControl
+}
+
+func (r *Room) resetClocks() {
+	tc := r.TimeControl()
+	if tc == nil {
+		r.Clocks = nil
+		return
+	}
+
+	r.Clocks = make([]int, len(r.Sides))
+	for i := range r.Clocks {
+		r.Clocks[i] = tc.Bank * 1000
+	}
+}
+
+// timed tells whether the decision runs the clock, the deal does not.
+func timed(kind DecisionKind) bool {
+	return kind != DecisionNone && kind != DecisionShuffle
+}
+
+// TimeLeft returns the time the side deciding now has till the expire
+// action.
+func (r *Room) TimeLeft(now time.Time) (int, time.Duration, bool) {
+	tc := r.TimeControl()
+	index, kind := r.Decision()
+	if tc == nil || !timed(kind) || len(r.Clocks) != len(r.Sides) {
+		return -1, 0, false
+	}
+
+	left := time.Duration(tc.Increment)*time.Second +
+		time.Duration(r.Clocks[index])*time.Millisecond -
+		now.Sub(r.Game.DecisionSince)
+	if left < 0 {
+		left = 0
+	}
+
+	return index, left, true
+}
+
+// chargeClock takes the time over the increment from the bank of the side.
+func (r *Room) chargeClock(index int, now time.Time) {
+	tc := r.TimeControl()
+	if tc == nil || len(r.Clocks) != len(r.Sides) {
+		return
+	}
+
+	over := now.Sub(r.Game.DecisionSince) - time.Duration(tc.Increment)*time.Second
+	if over <= 0 {
+		return
+	}
+
+	r.Clocks[index] -= int(over / time.Millisecond)
+	if r.Clocks[index] < 0 {
+		r.Clocks[index] = 0
+	}
+}
+
+// ExpireAction is the decision made for the side out of time: a pass in the
+// bidding and the whisting, the lowest legal card in the play and the
+// fallback bot otherwise.
+func (r *Room) ExpireAction(index int, kind DecisionKind) Action {
+	switch kind {
+	case DecisionBid:
+		return Action{Bid: BidPass}
+	case DecisionWhist:
+		return Action{Whist: false}
+	case DecisionMove:
+		legal := r.LegalMoves(index)
+		hand := r.Sides[index].Cards
+		best := legal[0]
+		for _, i := range legal {
+			if hand[i].rankNumber() < hand[best].rankNumber() {
+				best = i
+			}
+		}
+		return Action{Index: best}
+	}
+
+	return Strategies[FallbackStrategy](0).Decide(r.ViewFor(index))
+}
+
+// Forfeit finishes the pulka as it is for the player out of time, the
+// unfinished deal is not archived.
+func (r *Room) Forfeit(playerName string) error {
+	if r.Game == nil || r.Game.Finished {
+		return errors.New("deal is finished")
+	}
+
+	r.Game.Finished = true
+	r.Game.Turn = -1
+	r.Game.Archived = true
+	if r.Score != nil {
+		r.Score.Finished = true
+		r.Score.Forfeit = playerName
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClocks(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	room.Settings = &RoomSettings{
+		MaxPlayers:  4,
+		TimeControl: &TimeControl{Increment: 10, Bank: 60, OnExpire: ExpireAuto},
+	}
+	room.Score = NewScoreSheet([]string{"evgsol", "solarka", "psmirnov"}, ConventionSochi, 10)
+	require.NoError(t, room.Shuffle("evgsol"))
+	assert.Equal(t, []int{60000, 60000, 60000, 60000}, room.Clocks)
+
+	now := time.Now()
+	room.Game.DecisionSince = now.Add(-30 * time.Second)
+	index, left, ok := room.TimeLeft(now)
+	require.True(t, ok)
+	assert.Equal(t, 40*time.Second, left)
+
+	_, kind := room.Decision()
+	require.Equal(t, DecisionBid, kind)
+	assert.Equal(t, Action{Bid: BidPass}, room.ExpireAction(index, kind))
+	require.NoError(t, room.Apply(room.Sides[index].Name, kind, Action{Bid: BidPass}))
+	assert.InDelta(t, 40000, room.Clocks[index], 100)
+
+	room.Game.DecisionSince = now.Add(-2 * time.Minute)
+	_, left, _ = room.TimeLeft(now)
+	assert.Equal(t, time.Duration(0), left)
+
+	require.NoError(t, room.Forfeit("solarka"))
+	assert.True(t, room.Score.Finished)
+	assert.Equal(t, "solarka", room.Score.Forfeit)
+	_, kind = room.Decision()
+	assert.Equal(t, DecisionNone, kind)
+}
+
+func TestExpireMove(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	require.NoError(t, room.Shuffle("evgsol"))
+	strategy := &HeuristicStrategy{}
+	for {
+		index, kind := room.Decision()
+		if kind == DecisionMove {
+			action := room.ExpireAction(index, kind)
+			hand := room.Sides[index].Cards
+			for _, i := range room.LegalMoves(index) {
+				assert.True(t, hand[action.Index].rankNumber() <= hand[i].rankNumber())
+			}
+			require.NoError(t, room.Apply(room.Sides[index].Name, kind, action))
+			return
+		}
+		require.NoError(t, room.Apply(room.Sides[index].Name, kind, strategy.Decide(room.ViewFor(index))))
+	}
+}
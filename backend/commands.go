@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/globalsign/mgo"
+)
+
+var commands = map[string]func(args []string) error{
+	"verify":         verifyCommand,
+	"arena":          arenaCommand,
+	"backfill-stats": backfillStatsCommand,
+}
+
+func runCommand(name string, args []string) error {
+	command, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	return command(args)
+}
+
+// verifyCommand reads a DealReveal as JSON (from -file or stdin) and
+// prints the recomputed hands.
+func verifyCommand(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	filename := flags.String("file", "", "revealed deal JSON, stdin by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var input io.Reader = os.Stdin
+	if *filename != "" {
+		file, err := os.Open(*filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var reveal DealReveal
+	if err := json.NewDecoder(input).Decode(&reveal); err != nil {
+		return err
+	}
+
+	hands, err := VerifyDeal(reveal)
+	if err != nil {
+		return err
+	}
+
+	for i, hand := range hands {
+		var cards []string
+		for _, c := range hand {
+			cards = append(cards, c.Rank+string(c.Suit))
+		}
+		fmt.Printf("%d: %s\n", i, strings.Join(cards, " "))
+	}
+
+	return nil
+}
+
+func arenaCommand(args []string) error {
+	flags := flag.NewFlagSet("arena", flag.ContinueOnError)
+	strategies := flags.String("strategies", "heuristic,heuristic,random", "comma separated strategies by seat, 3 or 4")
+	pulkas := flags.Int("pulkas", 1000, "number of pulkas, or sets of deals with -duplicate")
+	pulkaSize := flags.Int("pulka-size", DefaultPulkaSize, "pulka size")
+	convention := flags.String("convention", string(ConventionSochi), "sochi or leningrad")
+	seed := flags.Int64("seed", 1, "seed of the deals")
+	duplicate := flags.Bool("duplicate", false, "replay every set of deals rotating the seats")
+	maxDeals := flags.Int("max-deals", 200, "settle a pulka after this number of deals")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	arena := &Arena{
+		Strategies: strings.Split(*strategies, ","),
+		PulkaSize:  *pulkaSize,
+		Convention: Convention(*convention),
+		Seed:       *seed,
+		Duplicate:  *duplicate,
+		MaxDeals:   *maxDeals,
+	}
+
+	stats, err := arena.Run(*pulkas)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-12s %8s %10s %10s %22s\n", "strategy", "samples", "mean", "stddev", "95% interval")
+	for _, s := range stats {
+		fmt.Printf("%-12s %8d %10.2f %10.2f [%9.2f, %9.2f]\n", s.Strategy, s.Samples, s.Mean, s.StdDev, s.Low, s.High)
+	}
+
+	return nil
+}
+
+// backfillStatsCommand counts the player stats of the archived deals anew,
+// the server should be stopped meanwhile.
+func backfillStatsCommand(args []string) error {
+	if err := Config.Init(CONFIGFILE); err != nil {
+		return err
+	}
+
+	session, err := mgo.Dial(Config.MongoURL)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	return NewRoomManager(NewRoomDAO(session)).BackfillStats(context.Background())
+}
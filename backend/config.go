@@ -3,11 +3,25 @@ package main
 import (
 	"encoding/json"
 	"os"
+	"time"
 )
 
 type Configuration struct {
-	Hostnames []string `json:"hostnames"`
-	MongoURL  string   `json:"mongo"`
+	Hostnames    []string `json:"hostnames"`
+	MongoURL     string   `json:"mongo"`
+	BotTimeLimit int      `json:"botTimeLimit"`
+	ChatFilter   []string `json:"chatFilter"`
+}
+
+const DefaultBotTimeLimit = 10 * time.Second
+
+// BotDecisionTime is the time an external bot has for a decision.
+func (c *Configuration) BotDecisionTime() time.Duration {
+	if c.BotTimeLimit <= 0 {
+		return DefaultBotTimeLimit
+	}
+
+	return time.Duration(c.BotTimeLimit) * time.Second
 }
 
 func (c *Configuration) Init(filename string) error {
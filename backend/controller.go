@@ -1,36 +1,80 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 type Controller struct {
 	roomManager *RoomManager
+	botDriver   *BotDriver
+	hints       *HintCache
+	presence    *Presence
+	matchmaker  *Matchmaker
+	tournaments *TournamentManager
 }
 
 func NewController(m *RoomManager) *Controller {
 	return &Controller{
 		roomManager: m,
+		botDriver:   NewBotDriver(m),
+		hints:       NewHintCache(),
+		presence:    NewPresence(),
+		matchmaker:  NewMatchmaker(m),
+		tournaments: NewTournamentManager(m),
 	}
 }
 
+// playBots lets the bots answer the player's action. Their failures do not
+// fail the action itself.
+func (c *Controller) playBots(ctx context.Context, roomID RoomID) (interface{}, error) {
+	if err := c.botDriver.Play(ctx, roomID); err != nil {
+		log.Println(err)
+	}
+
+	return nil, nil
+}
+
 func (c *Controller) Room(request *http.Request, playerName string) (interface{}, error) {
 	result, err := c.roomManager.GetOneForPlayer(request.Context(), playerName)
 	if err != nil {
 		return nil, err
 	}
 
-	if result != nil {
-		for i, _ := range result.Sides {
-			if result.Sides[i].Name == playerName || result.Sides[i].Open {
-				continue
-			}
-			for j, _ := range result.Sides[i].Cards {
-				result.Sides[i].Cards[j] = UnknownCard
-			}
+	if result == nil {
+		result, err = c.roomManager.GetOneForSpectator(request.Context(), playerName)
+		if err != nil || result == nil {
+			return nil, err
+		}
+		result.HideCards(EMPTY_SIDE)
+		result.Clock = result.ClockView(time.Now())
+		result.Chat, err = c.roomManager.GetMessages(request.Context(), result.ID, playerName, ZeroRoomID(), ChatInView)
+		if err != nil {
+			return nil, err
 		}
+		return result, nil
+	}
+
+	result.SetTurn(playerName)
+	if result.WantsHint(playerName) {
+		index := result.PlayerSideIndex(playerName)
+		if hint := c.hints.Lookup(result.Sides[index].Cards); hint != nil {
+			result.Hint = hint.Suggest(result.openBids(index))
+		}
+	}
+	result.HideCards(playerName)
+	result.Seats = result.RelativeSeats(playerName)
+	result.Clock = result.ClockView(time.Now())
+	result.PreMove = result.PreMoveOf(playerName)
+	result.Chat, err = c.roomManager.GetMessages(request.Context(), result.ID, playerName, ZeroRoomID(), ChatInView)
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -46,7 +90,7 @@ func (c *Controller) Shuffle(request *http.Request, playerName string) (interfac
 		return nil, err
 	}
 
-	return nil, nil
+	return c.playBots(request.Context(), room.ID)
 }
 
 func (c *Controller) OpenBuypack(request *http.Request, playerName string) (interface{}, error) {
@@ -72,7 +116,7 @@ func (c *Controller) TakeBuypack(request *http.Request, playerName string) (inte
 		return nil, err
 	}
 
-	return nil, nil
+	return c.playBots(request.Context(), room.ID)
 }
 
 type Indexes struct {
@@ -94,7 +138,7 @@ func (c *Controller) Drop(request *http.Request, playerName string) (interface{}
 		return nil, err
 	}
 
-	return nil, nil
+	return c.playBots(request.Context(), room.ID)
 }
 
 type Index struct {
@@ -116,7 +160,7 @@ func (c *Controller) Move(request *http.Request, playerName string) (interface{}
 		return nil, err
 	}
 
-	return nil, nil
+	return c.playBots(request.Context(), room.ID)
 }
 
 func (c *Controller) TakeTrick(request *http.Request, playerName string) (interface{}, error) {
@@ -158,8 +202,142 @@ func (c *Controller) ChangeVisibility(request *http.Request, playerName string)
 	return nil, nil
 }
 
+type BidRequest struct {
+	Bid Bid `json:"bid"`
+}
+
+func (c *Controller) Bid(request *http.Request, playerName string) (interface{}, error) {
+	var req BidRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	room, err := c.roomManager.GetOneForPlayer(request.Context(), playerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.roomManager.Bid(request.Context(), room.ID, playerName, req.Bid); err != nil {
+		return nil, err
+	}
+
+	return c.playBots(request.Context(), room.ID)
+}
+
+func (c *Controller) Declare(request *http.Request, playerName string) (interface{}, error) {
+	var req BidRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	room, err := c.roomManager.GetOneForPlayer(request.Context(), playerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.roomManager.Declare(request.Context(), room.ID, playerName, req.Bid); err != nil {
+		return nil, err
+	}
+
+	return c.playBots(request.Context(), room.ID)
+}
+
+type WhistRequest struct {
+	Whist bool `json:"whist"`
+}
+
+func (c *Controller) Whist(request *http.Request, playerName string) (interface{}, error) {
+	var req WhistRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	room, err := c.roomManager.GetOneForPlayer(request.Context(), playerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.roomManager.Whist(request.Context(), room.ID, playerName, req.Whist); err != nil {
+		return nil, err
+	}
+
+	return c.playBots(request.Context(), room.ID)
+}
+
+type AddBotRequest struct {
+	Strategy string `json:"strategy"`
+}
+
+func (c *Controller) AddBot(request *http.Request, playerName string) (interface{}, error) {
+	var req AddBotRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	if err := c.roomManager.AddBot(request.Context(), playerName, req.Strategy); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+type RemoveBotRequest struct {
+	Side int `json:"side"`
+}
+
+func (c *Controller) RemoveBot(request *http.Request, playerName string) (interface{}, error) {
+	var req RemoveBotRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	if err := c.roomManager.RemoveBot(request.Context(), playerName, req.Side); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+type EntropyRequest struct {
+	Entropy string `json:"entropy"`
+}
+
+func (c *Controller) Entropy(request *http.Request, playerName string) (interface{}, error) {
+	var req EntropyRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	if err := c.roomManager.AddEntropy(request.Context(), playerName, req.Entropy); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+type VerifyDealResponse struct {
+	Hands [][]Card `json:"hands"`
+}
+
+func (c *Controller) VerifyDeal(request *http.Request) (interface{}, error) {
+	var reveal DealReveal
+	if err := json.NewDecoder(request.Body).Decode(&reveal); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	hands, err := VerifyDeal(reveal)
+	if err != nil {
+		return nil, err
+	}
+
+	return VerifyDealResponse{Hands: hands}, nil
+}
+
 type PlayerInRequest struct {
-	RoomID string `json:"roomId"`
+	RoomID   string `json:"roomId"`
+	Seat     *int   `json:"seat"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
 }
 
 func (c *Controller) PlayerIn(request *http.Request, playerName string) (interface{}, error) {
@@ -173,12 +351,22 @@ func (c *Controller) PlayerIn(request *http.Request, playerName string) (interfa
 		return nil, err
 	}
 
-	err = c.roomManager.PlayerIn(request.Context(), roomID, playerName)
+	seat := AnySeat
+	if req.Seat != nil {
+		seat = *req.Seat
+	}
+
+	err = c.roomManager.Join(request.Context(), roomID, playerName, seat, req.Password, req.Token)
 	if err != nil {
 		return nil, err
 	}
 
-	return nil, nil
+	if IsAPIKeyRequest(request) {
+		return nil, c.roomManager.SetExternalBot(request.Context(), playerName)
+	}
+
+	// The bots go on if the player has returned to a paused game.
+	return c.playBots(request.Context(), roomID)
 }
 
 func (c *Controller) PlayerOut(request *http.Request, playerName string) (interface{}, error) {
@@ -199,20 +387,760 @@ func (c *Controller) RoomReady(request *http.Request, playerName string) (interf
 	return nil, nil
 }
 
+// CreateRoomRequest is optional, an empty body creates a public room for four
+// players.
+type CreateRoomRequest struct {
+	Private      bool         `json:"private"`
+	Password     string       `json:"password"`
+	MaxPlayers   int          `json:"maxPlayers"`
+	GraceSeconds int          `json:"graceSeconds"`
+	TimeControl  *TimeControl `json:"timeControl"`
+}
+
+func (req CreateRoomRequest) settings() (*RoomSettings, error) {
+	if req.GraceSeconds < 0 || req.GraceSeconds > MaxGraceSeconds {
+		return nil, errors.New("wrong grace period")
+	}
+
+	settings, err := NewRoomSettings(req.Private, req.Password, req.MaxPlayers)
+	if err != nil {
+		return nil, err
+	}
+	settings.GraceSeconds = req.GraceSeconds
+
+	if req.TimeControl != nil {
+		if req.TimeControl.OnExpire == "" {
+			req.TimeControl.OnExpire = ExpireAuto
+		}
+		if !req.TimeControl.Valid() {
+			return nil, errors.New("wrong time control")
+		}
+		settings.TimeControl = req.TimeControl
+	}
+
+	return settings, nil
+}
+
 func (c *Controller) CreateRoom(request *http.Request, playerName string) (interface{}, error) {
-	err := c.roomManager.CreateRoom(request.Context(), playerName)
+	var req CreateRoomRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil && err != io.EOF {
+		return nil, errors.New("bad request")
+	}
+
+	settings, err := req.settings()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.roomManager.CreateRoom(request.Context(), playerName, settings)
 	if err != nil {
 		return nil, err
 	}
 
+	if IsAPIKeyRequest(request) {
+		return nil, c.roomManager.SetExternalBot(request.Context(), playerName)
+	}
+
 	return nil, nil
 }
 
-func (c *Controller) GetRooms(request *http.Request) (interface{}, error) {
-	rooms, err := c.roomManager.GetAll(request.Context())
+func (c *Controller) GetRooms(request *http.Request, playerName string) (interface{}, error) {
+	rooms, err := c.roomManager.GetAll(request.Context(), playerName)
 	if err != nil {
 		return nil, err
 	}
 
 	return rooms, nil
 }
+
+type SwapSeatsRequest struct {
+	Player string `json:"player"`
+}
+
+func (c *Controller) SwapSeats(request *http.Request, playerName string) (interface{}, error) {
+	var req SwapSeatsRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return nil, c.roomManager.SwapSeats(request.Context(), playerName, req.Player)
+}
+
+func (c *Controller) AnswerSwap(request *http.Request, playerName string) (interface{}, error) {
+	var req SwitchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return nil, c.roomManager.AnswerSwap(request.Context(), playerName, req.Enabled)
+}
+
+type KickRequest struct {
+	Player string `json:"player"`
+}
+
+func (c *Controller) Kick(request *http.Request, playerName string) (interface{}, error) {
+	var req KickRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return nil, c.roomManager.Kick(request.Context(), playerName, req.Player)
+}
+
+func (c *Controller) Lock(request *http.Request, playerName string) (interface{}, error) {
+	var req SwitchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return nil, c.roomManager.Lock(request.Context(), playerName, req.Enabled)
+}
+
+func (c *Controller) RoomSettings(request *http.Request, playerName string) (interface{}, error) {
+	var req CreateRoomRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	settings, err := req.settings()
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.roomManager.UpdateSettings(request.Context(), playerName, settings)
+}
+
+func (c *Controller) CloseRoom(request *http.Request, playerName string) (interface{}, error) {
+	return nil, c.roomManager.CloseRoom(request.Context(), playerName)
+}
+
+func (c *Controller) VoteAbsence(request *http.Request, playerName string) (interface{}, error) {
+	var req AbsenceVote
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	if err := c.roomManager.VoteAbsence(request.Context(), playerName, req); err != nil {
+		return nil, err
+	}
+
+	room, err := c.roomManager.GetOneForPlayer(request.Context(), playerName)
+	if err != nil || room == nil {
+		return nil, err
+	}
+
+	return c.playBots(request.Context(), room.ID)
+}
+
+func (c *Controller) AutoPlay(request *http.Request, playerName string) (interface{}, error) {
+	var req SwitchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	if err := c.roomManager.SetAutoPlay(request.Context(), playerName, req.Enabled); err != nil {
+		return nil, err
+	}
+
+	room, err := c.roomManager.GetOneForPlayer(request.Context(), playerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.playBots(request.Context(), room.ID)
+}
+
+// PreMoveRequest queues the card, no card clears the queue.
+type PreMoveRequest struct {
+	Card *Card `json:"card"`
+}
+
+func (c *Controller) PreMove(request *http.Request, playerName string) (interface{}, error) {
+	var req PreMoveRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return nil, c.roomManager.QueuePreMove(request.Context(), playerName, req.Card)
+}
+
+type ProposeRequest struct {
+	Kind string `json:"kind"`
+}
+
+func (c *Controller) Propose(request *http.Request, playerName string) (interface{}, error) {
+	var req ProposeRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	roomID, err := c.roomManager.Propose(request.Context(), playerName, req.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.playBots(request.Context(), roomID)
+}
+
+func (c *Controller) VoteProposal(request *http.Request, playerName string) (interface{}, error) {
+	var req SwitchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	roomID, err := c.roomManager.VoteProposal(request.Context(), playerName, req.Enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	// The bots go on if the room has been resumed.
+	return c.playBots(request.Context(), roomID)
+}
+
+// ChatRequest asks for the messages before the given one, no message starts
+// from the latest.
+type ChatRequest struct {
+	RoomID string `json:"roomId"`
+	Before string `json:"before"`
+	Limit  int    `json:"limit"`
+}
+
+func (c *Controller) Chat(request *http.Request, playerName string) (interface{}, error) {
+	var req ChatRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	roomID, err := NewRoomIDFromString(req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := ZeroRoomID()
+	if req.Before != "" {
+		if before, err = NewRoomIDFromString(req.Before); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.roomManager.GetMessages(request.Context(), roomID, playerName, before, req.Limit)
+}
+
+type SendMessageRequest struct {
+	RoomID string `json:"roomId"`
+	Text   string `json:"text"`
+}
+
+func (c *Controller) SendMessage(request *http.Request, playerName string) (interface{}, error) {
+	var req SendMessageRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	roomID, err := NewRoomIDFromString(req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.roomManager.SendMessage(request.Context(), roomID, playerName, req.Text)
+}
+
+type MuteRequest struct {
+	Player string `json:"player"`
+	Muted  bool   `json:"muted"`
+}
+
+func (c *Controller) Mute(request *http.Request, playerName string) (interface{}, error) {
+	var req MuteRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return nil, c.roomManager.Mute(request.Context(), playerName, req.Player, req.Muted)
+}
+
+// Track marks the user of the request as online.
+func (c *Controller) Track(f func(*http.Request, string) (interface{}, error)) func(*http.Request, string) (interface{}, error) {
+	return c.presence.Track(f)
+}
+
+func (c *Controller) Online(request *http.Request, playerName string) (interface{}, error) {
+	return c.roomManager.Lobby(request.Context(), c.presence.Online(time.Now()))
+}
+
+func (c *Controller) Invitations(request *http.Request, playerName string) (interface{}, error) {
+	return c.roomManager.Invitations(request.Context(), playerName)
+}
+
+func (c *Controller) AcceptInvitation(request *http.Request, playerName string) (interface{}, error) {
+	var req WatchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	roomID, err := NewRoomIDFromString(req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.roomManager.Join(request.Context(), roomID, playerName, AnySeat, "", "")
+}
+
+func (c *Controller) DeclineInvitation(request *http.Request, playerName string) (interface{}, error) {
+	var req WatchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	roomID, err := NewRoomIDFromString(req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.roomManager.DeclineInvitation(request.Context(), roomID, playerName)
+}
+
+type FindGameRequest struct {
+	Convention Convention `json:"convention"`
+	Stake      int        `json:"stake"`
+	Players    int        `json:"players"`
+}
+
+func (c *Controller) FindGame(request *http.Request, playerName string) (interface{}, error) {
+	var req FindGameRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	rating, err := c.roomManager.PlayerRating(request.Context(), playerName)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := MatchTicket{
+		Player:     playerName,
+		Convention: req.Convention,
+		Stake:      req.Stake,
+		Players:    req.Players,
+		Rating:     rating,
+		Since:      time.Now(),
+	}
+	if err := c.matchmaker.Enqueue(request.Context(), ticket); err != nil {
+		return nil, err
+	}
+
+	return c.matchmaker.Status(playerName), nil
+}
+
+func (c *Controller) LeaveQueue(request *http.Request, playerName string) (interface{}, error) {
+	return nil, c.matchmaker.Leave(playerName)
+}
+
+func (c *Controller) Queue(request *http.Request, playerName string) (interface{}, error) {
+	return c.matchmaker.Status(playerName), nil
+}
+
+// CreateTournamentRequest takes the boards of the duplicate tournament or
+// the rounds of the Swiss one.
+type CreateTournamentRequest struct {
+	Name       string     `json:"name"`
+	Format     string     `json:"format"`
+	Convention Convention `json:"convention"`
+	Boards     int        `json:"boards"`
+	Rounds     int        `json:"rounds"`
+}
+
+type TournamentRequest struct {
+	TournamentID string `json:"tournamentId"`
+}
+
+func (c *Controller) CreateTournament(request *http.Request, playerName string) (interface{}, error) {
+	var req CreateTournamentRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	var id TournamentID
+	var err error
+	switch req.Format {
+	case "", FormatDuplicate:
+		id, err = c.tournaments.Create(request.Context(), playerName, req.Name, req.Convention, req.Boards)
+	case FormatSwiss:
+		id, err = c.tournaments.CreateSwiss(request.Context(), playerName, req.Name, req.Convention, req.Rounds)
+	default:
+		return nil, errors.New("wrong format")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return TournamentRequest{TournamentID: id.String()}, nil
+}
+
+func (c *Controller) tournamentID(request *http.Request) (TournamentID, error) {
+	var req TournamentRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return TournamentID{}, errors.New("bad request")
+	}
+
+	return NewRoomIDFromString(req.TournamentID)
+}
+
+func (c *Controller) RegisterTournament(request *http.Request, playerName string) (interface{}, error) {
+	id, err := c.tournamentID(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.tournaments.Register(request.Context(), id, playerName)
+}
+
+func (c *Controller) StartTournament(request *http.Request, playerName string) (interface{}, error) {
+	id, err := c.tournamentID(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.tournaments.Start(request.Context(), id, playerName)
+}
+
+func (c *Controller) Tournament(request *http.Request, playerName string) (interface{}, error) {
+	id, err := c.tournamentID(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.tournaments.Get(request.Context(), id)
+}
+
+type NoShowRequest struct {
+	TournamentID string `json:"tournamentId"`
+	Player       string `json:"player"`
+}
+
+func (c *Controller) NoShow(request *http.Request, playerName string) (interface{}, error) {
+	var req NoShowRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	id, err := NewRoomIDFromString(req.TournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.tournaments.NoShow(request.Context(), id, playerName, req.Player)
+}
+
+type InviteRequest struct {
+	Player string `json:"player"`
+}
+
+type InviteResponse struct {
+	RoomID string `json:"roomId"`
+	Token  string `json:"token"`
+}
+
+func (c *Controller) Invite(request *http.Request, playerName string) (interface{}, error) {
+	var req InviteRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil && err != io.EOF {
+		return nil, errors.New("bad request")
+	}
+
+	roomID, token, err := c.roomManager.Invite(request.Context(), playerName, req.Player)
+	if err != nil {
+		return nil, err
+	}
+
+	return InviteResponse{RoomID: roomID.String(), Token: token}, nil
+}
+
+const (
+	DefaultBotWait = 30 * time.Second
+	MaxBotWait     = 60 * time.Second
+	BotPollPeriod  = 500 * time.Millisecond
+)
+
+type BotDecisionResponse struct {
+	BotView
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+// BotDecision waits up to "wait" seconds until the player has to act and
+// returns the decision, an empty kind means there is nothing to do yet.
+func (c *Controller) BotDecision(request *http.Request, playerName string) (interface{}, error) {
+	wait := DefaultBotWait
+	if raw := request.URL.Query().Get("wait"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 {
+			return nil, errors.New("bad request")
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait > MaxBotWait {
+		wait = MaxBotWait
+	}
+
+	ticker := time.NewTicker(BotPollPeriod)
+	defer ticker.Stop()
+	timeout := time.After(wait)
+
+	for {
+		room, err := c.roomManager.GetOneForPlayer(request.Context(), playerName)
+		if err != nil {
+			return nil, err
+		}
+
+		if room != nil {
+			index, kind := room.Decision()
+			if kind != DecisionNone && room.Sides[index].Name == playerName {
+				deadline := room.Game.DecisionSince.Add(Config.BotDecisionTime())
+				return BotDecisionResponse{
+					BotView:  room.ViewFor(index),
+					Deadline: &deadline,
+				}, nil
+			}
+		}
+
+		select {
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		case <-timeout:
+			return BotDecisionResponse{}, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Controller) BotAct(request *http.Request, playerName string) (interface{}, error) {
+	var action Action
+	if err := json.NewDecoder(request.Body).Decode(&action); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	room, err := c.roomManager.GetOneForPlayer(request.Context(), playerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if room == nil {
+		return nil, errors.New("player is not in room")
+	}
+
+	index, kind := room.Decision()
+	if kind == DecisionNone || room.Sides[index].Name != playerName {
+		return nil, errors.New("not your turn")
+	}
+
+	if err := c.roomManager.Act(request.Context(), room.ID, playerName, kind, action); err != nil {
+		return nil, err
+	}
+
+	return c.playBots(request.Context(), room.ID)
+}
+
+func (c *Controller) Deals(request *http.Request, playerName string) (interface{}, error) {
+	return c.roomManager.GetDeals(request.Context(), playerName)
+}
+
+// StatsRequest takes the days as 2006-01-02, the player is the user by
+// default.
+type StatsRequest struct {
+	Player    string   `json:"player"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Opponents []string `json:"opponents"`
+}
+
+func (c *Controller) Stats(request *http.Request, playerName string) (interface{}, error) {
+	var req StatsRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil && err != io.EOF {
+		return nil, errors.New("bad request")
+	}
+
+	if req.Player != "" {
+		playerName = req.Player
+	}
+
+	from, err := parseDay(req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseDay(req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := StatsFilter{From: from, To: to, Opponents: req.Opponents}
+	return c.roomManager.Stats(request.Context(), playerName, filter)
+}
+
+// parseDay reads an optional day, the empty one is zero.
+func parseDay(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, errors.New("wrong date")
+	}
+
+	return t, nil
+}
+
+type AnalysisRequest struct {
+	DealID string `json:"dealId"`
+}
+
+func (c *Controller) Analysis(request *http.Request, playerName string) (interface{}, error) {
+	var req AnalysisRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	dealID, err := NewRoomIDFromString(req.DealID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.roomManager.Analyse(request.Context(), dealID, playerName)
+}
+
+func (c *Controller) Mistakes(request *http.Request, playerName string) (interface{}, error) {
+	var req AnalysisRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	dealID, err := NewRoomIDFromString(req.DealID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.roomManager.Mistakes(request.Context(), dealID, playerName)
+}
+
+type SwitchRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+func (c *Controller) Practice(request *http.Request, playerName string) (interface{}, error) {
+	var req SwitchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return nil, c.roomManager.SetPractice(request.Context(), playerName, req.Enabled)
+}
+
+func (c *Controller) Hints(request *http.Request, playerName string) (interface{}, error) {
+	var req SwitchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return nil, c.roomManager.SetHints(request.Context(), playerName, req.Enabled)
+}
+
+type OddsRequest struct {
+	Hand     []Card `json:"hand"`
+	Known    []Card `json:"known"`
+	Holdings []int  `json:"holdings"`
+}
+
+func (c *Controller) Odds(request *http.Request) (interface{}, error) {
+	var req OddsRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return CalculateOdds(req.Hand, req.Known, req.Holdings)
+}
+
+func (c *Controller) Puzzles(request *http.Request) (interface{}, error) {
+	return Puzzles, nil
+}
+
+type StartPuzzleRequest struct {
+	PuzzleID string `json:"puzzleId"`
+}
+
+func (c *Controller) StartPuzzle(request *http.Request, playerName string) (interface{}, error) {
+	var req StartPuzzleRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	puzzle, err := FindPuzzle(req.PuzzleID)
+	if err != nil {
+		return nil, err
+	}
+
+	room, err := c.roomManager.StartPuzzle(request.Context(), playerName, puzzle)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.playBots(request.Context(), room.ID)
+}
+
+type WatchRequest struct {
+	RoomID string `json:"roomId"`
+}
+
+func (c *Controller) Watch(request *http.Request, playerName string) (interface{}, error) {
+	var req WatchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	roomID, err := NewRoomIDFromString(req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.roomManager.Watch(request.Context(), roomID, playerName)
+}
+
+func (c *Controller) Unwatch(request *http.Request, playerName string) (interface{}, error) {
+	return nil, c.roomManager.Unwatch(request.Context(), playerName)
+}
+
+func (c *Controller) Spectating(request *http.Request, playerName string) (interface{}, error) {
+	var req SwitchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return nil, c.roomManager.SetSpectating(request.Context(), playerName, req.Enabled)
+}
+
+type KibitzersRequest struct {
+	Kibitzers []string `json:"kibitzers"`
+	KibitzDelay
+}
+
+func (c *Controller) Kibitzers(request *http.Request, playerName string) (interface{}, error) {
+	var req KibitzersRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	return nil, c.roomManager.SetKibitzers(request.Context(), playerName, req.Kibitzers, req.KibitzDelay)
+}
+
+func (c *Controller) Kibitz(request *http.Request, playerName string) (interface{}, error) {
+	var req WatchRequest
+	if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+		return nil, errors.New("bad request")
+	}
+
+	roomID, err := NewRoomIDFromString(req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.roomManager.Kibitz(request.Context(), roomID, playerName)
+}
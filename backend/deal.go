@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	DealCollectionName = "deals"
+
+	MaxListedDeals = 50
+)
+
+type DealID = RoomID
+
+// ArchivedDeal is a finished deal with all its cards. The hands are the
+// dealt ones in the order of play, see DealResult for the players.
+type ArchivedDeal struct {
+	ID         DealID           `json:"id" bson:"_id"`
+	RoomID     RoomID           `json:"roomId" bson:"roomId"`
+	Finished   time.Time        `json:"finished" bson:"finished"`
+	Hands      [][]Card         `json:"hands" bson:"hands"`
+	Buypack    []Card           `json:"buypack" bson:"buypack"`
+	Dropped    []Card           `json:"dropped" bson:"dropped"`
+	Bids       []BidInfo        `json:"bids" bson:"bids"`
+	Whists     []WhistInfo      `json:"whists" bson:"whists"`
+	Plays      []CenterCardInfo `json:"plays" bson:"plays"`
+	Result     DealResult       `json:"result" bson:"result"`
+	Seed       *DealReveal      `json:"seed,omitempty" bson:"seed,omitempty"`
+	Convention Convention       `json:"convention,omitempty" bson:"convention,omitempty"`
+	// Balances are the changes of the sheet balances by the deal, aligned
+	// with the players of the result.
+	Balances []float64 `json:"balances,omitempty" bson:"balances,omitempty"`
+	Board    *BoardRef `json:"board,omitempty" bson:"board,omitempty"`
+	Puzzle   bool      `json:"puzzle,omitempty" bson:"puzzle,omitempty"`
+	// Review is the stored mistakes report, it is computed on the first
+	// request.
+	Review *MistakesReport `json:"-" bson:"review,omitempty"`
+}
+
+func NewArchivedDeal(room *Room) *ArchivedDeal {
+	res := &ArchivedDeal{
+		ID:       NewRoomID(),
+		RoomID:   room.ID,
+		Finished: time.Now(),
+		Hands:    room.Game.Hands,
+		Buypack:  room.Game.Buypack,
+		Dropped:  room.Game.Dropped,
+		Bids:     room.Game.Bids,
+		Whists:   room.Game.Whists,
+		Plays:    room.Game.Plays,
+		Result:   room.DealResult(),
+		Seed:     room.LastReveal,
+		Balances: room.Game.Balances,
+		Board:    room.BoardRef(),
+		Puzzle:   room.Puzzle != nil,
+	}
+	if room.Score != nil {
+		res.Convention = room.Score.Convention
+	}
+
+	return res
+}
+
+func (d *ArchivedDeal) seat(playerName string) int {
+	for i, name := range d.Result.Players {
+		if name == playerName {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (d *ArchivedDeal) trump() Suit {
+	if d.Result.Contract == "" {
+		return SuitNoTrump
+	}
+
+	return d.Result.Contract.Trump()
+}
+
+// Position returns the open position after the first plays of the deal.
+func (d *ArchivedDeal) Position(plays int) (DDPosition, error) {
+	if len(d.Hands) != 3 || plays < 0 || plays > len(d.Plays) {
+		return DDPosition{}, errors.New("wrong position")
+	}
+
+	pos := DDPosition{Trump: d.trump()}
+	for i, hand := range d.Hands {
+		if i == d.Result.Declarer {
+			hand = removeCards(append(append([]Card{}, hand...), d.Buypack...), d.Dropped)
+		}
+		pos.Hands = append(pos.Hands, append([]Card{}, hand...))
+	}
+	if d.Result.Contract == "" {
+		pos.Talon = append([]Card{}, d.Buypack...)
+	}
+
+	for _, played := range d.Plays[:plays] {
+		if d.seat(played.Player) != pos.Turn() {
+			return DDPosition{}, errors.New("wrong player")
+		}
+		pos, _ = pos.Play(played.Card)
+	}
+
+	return pos, nil
+}
+
+func removeCards(hand []Card, cards []Card) []Card {
+	var res []Card
+	for _, c := range hand {
+		removed := false
+		for _, other := range cards {
+			if c == other {
+				removed = true
+			}
+		}
+		if !removed {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
+
+// DealAnalysis compares the double dummy tricks with the actual ones. The
+// sides of a contract are the declarer and the defence, in all pass every
+// player is a side of their own.
+type DealAnalysis struct {
+	Deal    *ArchivedDeal `json:"deal"`
+	Sides   [][]string    `json:"sides"`
+	Optimal []int         `json:"optimal"`
+	Actual  []int         `json:"actual"`
+}
+
+func (d *ArchivedDeal) Analyse() (*DealAnalysis, error) {
+	pos, err := d.Position(0)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DealAnalysis{Deal: d}
+	if d.Result.Contract == "" {
+		for seat, name := range d.Result.Players {
+			tricks, err := SolveTricks(pos, seat, true)
+			if err != nil {
+				return nil, err
+			}
+			res.Sides = append(res.Sides, []string{name})
+			res.Optimal = append(res.Optimal, tricks)
+			res.Actual = append(res.Actual, d.Result.Tricks[seat])
+		}
+		return res, nil
+	}
+
+	declarer := d.Result.Declarer
+	tricks, err := SolveTricks(pos, declarer, d.Result.Contract.IsMisere())
+	if err != nil {
+		return nil, err
+	}
+
+	var defence []string
+	for seat, name := range d.Result.Players {
+		if seat != declarer {
+			defence = append(defence, name)
+		}
+	}
+	res.Sides = [][]string{{d.Result.Players[declarer]}, defence}
+	res.Optimal = []int{tricks, len(pos.Hands[declarer]) - tricks}
+	res.Actual = []int{d.Result.Tricks[declarer], 0}
+	for seat, t := range d.Result.Tricks {
+		if seat != declarer {
+			res.Actual[1] += t
+		}
+	}
+
+	return res, nil
+}
+
+// PlayMistake is a card that gave away tricks of the player's side with the
+// best play of everybody, Best are the cards that did not.
+type PlayMistake struct {
+	Play   int    `json:"play"`
+	Player string `json:"player"`
+	Card   Card   `json:"card"`
+	Best   []Card `json:"best"`
+	Lost   int    `json:"lost"`
+}
+
+type MistakesReport struct {
+	Deal     *ArchivedDeal  `json:"deal" bson:"-"`
+	Mistakes []PlayMistake  `json:"mistakes" bson:"mistakes"`
+	Lost     map[string]int `json:"lost" bson:"lost"`
+}
+
+// goal returns whose tricks are counted for the seat and whether they are
+// minimized: the declarer's ones in a contract, the own ones in all pass.
+func (d *ArchivedDeal) goal(seat int) (int, bool) {
+	if d.Result.Contract == "" {
+		return seat, true
+	}
+
+	return d.Result.Declarer, d.Result.Contract.IsMisere()
+}
+
+// Mistakes walks the plays and compares every card with the best ones.
+func (d *ArchivedDeal) Mistakes() (*MistakesReport, error) {
+	res := &MistakesReport{
+		Deal:     d,
+		Mistakes: []PlayMistake{},
+		Lost:     map[string]int{},
+	}
+	for _, name := range d.Result.Players {
+		res.Lost[name] = 0
+	}
+
+	// value counts the target's tricks after the card, including the trick
+	// it completes.
+	value := func(pos DDPosition, card Card, target int, minimize bool) (int, error) {
+		next, winner := pos.Play(card)
+		tricks, err := SolveTricks(next, target, minimize)
+		if err != nil {
+			return 0, err
+		}
+		if winner == target {
+			tricks++
+		}
+		return tricks, nil
+	}
+
+	pos, err := d.Position(0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, played := range d.Plays {
+		seat := pos.Turn()
+		target, minimize := d.goal(seat)
+		optimal, err := SolveTricks(pos, target, minimize)
+		if err != nil {
+			return nil, err
+		}
+		actual, err := value(pos, played.Card, target, minimize)
+		if err != nil {
+			return nil, err
+		}
+
+		lost := actual - optimal
+		if (seat == target) != minimize {
+			lost = -lost
+		}
+		if lost > 0 {
+			mistake := PlayMistake{
+				Play:   i,
+				Player: played.Player,
+				Card:   played.Card,
+				Lost:   lost,
+			}
+			for _, c := range pos.Legal() {
+				v, err := value(pos, c, target, minimize)
+				if err != nil {
+					return nil, err
+				}
+				if v == optimal {
+					mistake.Best = append(mistake.Best, c)
+				}
+			}
+			res.Mistakes = append(res.Mistakes, mistake)
+			res.Lost[played.Player] += lost
+		}
+
+		pos, _ = pos.Play(played.Card)
+	}
+
+	return res, nil
+}
+
+type DealDAO struct {
+	collection *mgo.Collection
+}
+
+func NewDealDAO(session *mgo.Session) *DealDAO {
+	return &DealDAO{
+		collection: session.DB(RoomDatabaseName).C(DealCollectionName),
+	}
+}
+
+func (d *DealDAO) FindOneByID(ctx context.Context, dealID DealID) (*ArchivedDeal, error) {
+	var result ArchivedDeal
+	if err := d.collection.FindId(dealID).One(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (d *DealDAO) FindByPlayer(ctx context.Context, playerName string, limit int) ([]ArchivedDeal, error) {
+	var result []ArchivedDeal
+	if err := d.collection.Find(bson.M{"result.players": playerName}).Sort("-finished").Limit(limit).All(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (d *DealDAO) FindByTournament(ctx context.Context, id TournamentID) ([]ArchivedDeal, error) {
+	var result []ArchivedDeal
+	if err := d.collection.Find(bson.M{"board.tournamentId": id}).All(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ForEach calls f for every archived deal until it fails.
+func (d *DealDAO) ForEach(ctx context.Context, f func(deal *ArchivedDeal) error) error {
+	iter := d.collection.Find(nil).Iter()
+	var deal ArchivedDeal
+	for iter.Next(&deal) {
+		if err := f(&deal); err != nil {
+			iter.Close()
+			return err
+		}
+		deal = ArchivedDeal{}
+	}
+
+	return iter.Close()
+}
+
+func (d *DealDAO) Insert(ctx context.Context, deal *ArchivedDeal) error {
+	return d.collection.Insert(deal)
+}
+
+func (d *DealDAO) SetReview(ctx context.Context, dealID DealID, review *MistakesReport) error {
+	return d.collection.UpdateId(dealID, bson.M{"$set": bson.M{"review": review}})
+}
+
+func (d *DealDAO) RemoveAll(ctx context.Context) error {
+	_, err := d.collection.RemoveAll(bson.M{})
+	return err
+}
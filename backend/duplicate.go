@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Board is a preset deal of a duplicate tournament. Dealer is the position
+// among the occupied seats, so the same cards go to the same seats at every
+// table.
+type Board struct {
+	Number     int    `json:"number" bson:"number"`
+	ServerSeed string `json:"-" bson:"serverSeed"`
+	Dealer     int    `json:"dealer" bson:"dealer"`
+}
+
+func NewBoards(count int) ([]Board, error) {
+	var res []Board
+	for i := 0; i < count; i++ {
+		seed, err := NewDealSeed()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Board{
+			Number:     i,
+			ServerSeed: seed.ServerSeed,
+			Dealer:     i % 3,
+		})
+	}
+
+	return res, nil
+}
+
+// SeatMovement rotates the players of a table through the seats: on the
+// board b the j-th player of the table sits SeatMovement[b%6][j] seats after
+// the dealer. Over six boards every player holds every position twice and
+// plays both before and after each opponent.
+var SeatMovement = [][]int{
+	{0, 1, 2},
+	{1, 2, 0},
+	{2, 0, 1},
+	{0, 2, 1},
+	{2, 1, 0},
+	{1, 0, 2},
+}
+
+// DuplicateTable makes the room deal the boards of the tournament in order
+// instead of the random deals. Players are the ones of the table in the
+// order of SeatMovement.
+type DuplicateTable struct {
+	TournamentID TournamentID `json:"tournamentId" bson:"tournamentId"`
+	Table        int          `json:"table" bson:"table"`
+	Players      []string     `json:"players,omitempty" bson:"players,omitempty"`
+	Boards       []Board      `json:"-" bson:"boards"`
+	Played       int          `json:"played" bson:"played"`
+}
+
+// BoardRef tells which board of which table the archived deal was.
+type BoardRef struct {
+	TournamentID TournamentID `json:"tournamentId" bson:"tournamentId"`
+	Table        int          `json:"table" bson:"table"`
+	Number       int          `json:"number" bson:"number"`
+}
+
+// nextBoard sets the seed of the next board and returns its dealer.
+func (r *Room) nextBoard() (int, error) {
+	d := r.Duplicate
+	if d.Played >= len(d.Boards) {
+		return -1, errors.New("all boards are played")
+	}
+
+	var seats []int
+	for i, side := range r.Sides {
+		if side.Name != EMPTY_SIDE {
+			seats = append(seats, i)
+		}
+	}
+	if len(seats) != 3 {
+		return -1, errors.New("wrong players count")
+	}
+
+	board := d.Boards[d.Played]
+	if err := r.moveSeats(seats, board.Dealer, SeatMovement[d.Played%len(SeatMovement)]); err != nil {
+		return -1, err
+	}
+	d.Played++
+	r.NextSeed = &DealSeed{
+		ServerSeed: board.ServerSeed,
+		Commitment: SeedCommitment(board.ServerSeed),
+		Entropy:    map[string]string{},
+	}
+
+	return seats[board.Dealer], nil
+}
+
+// moveSeats seats the players of the table for the board, the cards and
+// tricks of the last deal stay at their sides while the clocks follow the
+// players. The tables without the players keep their seats.
+func (r *Room) moveSeats(seats []int, dealer int, movement []int) error {
+	players := r.Duplicate.Players
+	if len(players) == 0 {
+		return nil
+	}
+	if len(players) != len(seats) {
+		return errors.New("wrong players count")
+	}
+
+	from := make([]int, len(players))
+	for j, name := range players {
+		if from[j] = r.PlayerSideIndex(name); from[j] == -1 {
+			return errors.New("player is not seated")
+		}
+	}
+
+	sides := append([]RoomSideInfo{}, r.Sides...)
+	clocks := append([]int{}, r.Clocks...)
+	for j := range players {
+		to := seats[(dealer+movement[j])%len(seats)]
+		r.Sides[to].Name = sides[from[j]].Name
+		r.Sides[to].Bot = sides[from[j]].Bot
+		r.Sides[to].Open = sides[from[j]].Open
+		if len(clocks) == len(r.Sides) {
+			r.Clocks[to] = clocks[from[j]]
+		}
+	}
+	r.Swap = nil
+
+	return nil
+}
+
+func (r *Room) BoardRef() *BoardRef {
+	if r.Duplicate == nil || r.Duplicate.Played == 0 {
+		return nil
+	}
+
+	return &BoardRef{
+		TournamentID: r.Duplicate.TournamentID,
+		Table:        r.Duplicate.Table,
+		Number:       r.Duplicate.Played - 1,
+	}
+}
+
+// DealScore values the deal alone in whists, aligned with the players of
+// the result. The pulka is counted as the mountain written off, so the
+// results of the boards do not depend on the deals before.
+func DealScore(result DealResult, convention Convention) []float64 {
+	sheet := NewScoreSheet(result.Players, convention, math.MaxInt32)
+	sheet.Record(result)
+
+	res := sheet.Balances()
+	n := float64(len(sheet.Players))
+	total := 0
+	for _, p := range sheet.Players {
+		total += p.Pulka
+	}
+	for i, p := range sheet.Players {
+		res[i] += 10 * (n*float64(p.Pulka) - float64(total)) / n
+	}
+
+	return res
+}
+
+// BoardResult is the score of the player holding the cards of the position
+// on the board, Matchpoints are two for every table holding the same cards
+// with a worse score and one for a tie.
+type BoardResult struct {
+	Board       int     `json:"board"`
+	Position    int     `json:"position"`
+	Table       int     `json:"table"`
+	Player      string  `json:"player"`
+	Score       float64 `json:"score"`
+	Matchpoints int     `json:"matchpoints"`
+}
+
+type DuplicateStanding struct {
+	Player      string  `json:"player"`
+	Matchpoints int     `json:"matchpoints"`
+	Percent     float64 `json:"percent"`
+}
+
+type DuplicateReport struct {
+	Boards    []BoardResult       `json:"boards"`
+	Standings []DuplicateStanding `json:"standings"`
+}
+
+// NewDuplicateReport compares the archived boards across the tables. The
+// percent is of the matchpoints possible on the boards compared so far.
+func NewDuplicateReport(deals []ArchivedDeal, convention Convention) DuplicateReport {
+	type key struct{ board, position int }
+	groups := map[key][]BoardResult{}
+	for _, deal := range deals {
+		if deal.Board == nil {
+			continue
+		}
+		scores := DealScore(deal.Result, convention)
+		for position, name := range deal.Result.Players {
+			k := key{deal.Board.Number, position}
+			groups[k] = append(groups[k], BoardResult{
+				Board:    deal.Board.Number,
+				Position: position,
+				Table:    deal.Board.Table,
+				Player:   name,
+				Score:    scores[position],
+			})
+		}
+	}
+
+	res := DuplicateReport{Boards: []BoardResult{}, Standings: []DuplicateStanding{}}
+	possible := map[string]int{}
+	standings := map[string]*DuplicateStanding{}
+	for _, group := range groups {
+		for i := range group {
+			for j := range group {
+				if i == j {
+					continue
+				}
+				if group[i].Score > group[j].Score {
+					group[i].Matchpoints += 2
+				} else if group[i].Score == group[j].Score {
+					group[i].Matchpoints++
+				}
+			}
+
+			name := group[i].Player
+			if standings[name] == nil {
+				standings[name] = &DuplicateStanding{Player: name}
+			}
+			standings[name].Matchpoints += group[i].Matchpoints
+			possible[name] += 2 * (len(group) - 1)
+		}
+		res.Boards = append(res.Boards, group...)
+	}
+
+	sort.Slice(res.Boards, func(i, j int) bool {
+		a, b := res.Boards[i], res.Boards[j]
+		if a.Board != b.Board {
+			return a.Board < b.Board
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.Table < b.Table
+	})
+
+	for name, s := range standings {
+		if possible[name] > 0 {
+			s.Percent = 100 * float64(s.Matchpoints) / float64(possible[name])
+		}
+		res.Standings = append(res.Standings, *s)
+	}
+	sort.Slice(res.Standings, func(i, j int) bool {
+		if res.Standings[i].Percent != res.Standings[j].Percent {
+			return res.Standings[i].Percent > res.Standings[j].Percent
+		}
+		return res.Standings[i].Player < res.Standings[j].Player
+	})
+
+	return res
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateBoards(t *testing.T) {
+	boards, err := NewBoards(2)
+	require.NoError(t, err)
+
+	first := newTestRoom("evgsol", "solarka", "psmirnov")
+	first.Duplicate = &DuplicateTable{Table: 0, Boards: boards}
+	second := newTestRoom("pavel", "olga", "ivan")
+	second.Duplicate = &DuplicateTable{Table: 1, Boards: boards}
+
+	require.NoError(t, first.Shuffle("evgsol"))
+	require.NoError(t, second.Shuffle("olga"))
+	assert.Equal(t, first.Game.Dealer, second.Game.Dealer)
+	for i := range first.Sides {
+		assert.Equal(t, first.Sides[i].Cards, second.Sides[i].Cards)
+	}
+	assert.Equal(t, &BoardRef{Table: 1, Number: 0}, second.BoardRef())
+
+	first.Game.Finished = true
+	require.NoError(t, first.Shuffle("evgsol"))
+	assert.Equal(t, 1, first.Game.Dealer)
+	first.Game.Finished = true
+	assert.Error(t, first.Shuffle("evgsol"))
+}
+
+func TestDuplicateReport(t *testing.T) {
+	deal := func(table int, players []string, tricks []int) ArchivedDeal {
+		return ArchivedDeal{
+			Board: &BoardRef{Table: table, Number: 0},
+			Result: DealResult{
+				Players:  players,
+				Tricks:   tricks,
+				Contract: "6S",
+				Declarer: 0,
+				Whisted:  []bool{false, true, false},
+			},
+		}
+	}
+
+	scores := DealScore(deal(0, []string{"a", "b", "c"}, []int{6, 4, 0}).Result, ConventionSochi)
+	assert.InDelta(t, 0, scores[0]+scores[1]+scores[2], 1e-9)
+	assert.Greater(t, scores[0], 0.0)
+
+	report := NewDuplicateReport([]ArchivedDeal{
+		deal(0, []string{"evgsol", "solarka", "psmirnov"}, []int{6, 4, 0}),
+		deal(1, []string{"pavel", "olga", "ivan"}, []int{5, 5, 0}),
+		{Result: DealResult{Players: []string{"x", "y", "z"}}},
+	}, ConventionSochi)
+
+	require.Len(t, report.Boards, 6)
+	assert.Equal(t, BoardResult{Board: 0, Position: 0, Table: 0, Player: "evgsol", Score: report.Boards[0].Score, Matchpoints: 2}, report.Boards[0])
+	assert.Equal(t, 0, report.Boards[1].Matchpoints)
+	require.Len(t, report.Standings, 6)
+	assert.Equal(t, 100.0, report.Standings[0].Percent)
+}
+
+func TestTableSeating(t *testing.T) {
+	_, err := TableSeating([]string{"a", "b", "c"})
+	assert.Error(t, err)
+
+	seating, err := TableSeating([]string{"a", "b", "c", "d", "e", "f"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"d", "e", "f"}}, seating)
+}
+
+func TestSeatMovement(t *testing.T) {
+	boards, err := NewBoards(len(SeatMovement))
+	require.NoError(t, err)
+
+	first := newTestRoom("evgsol", "solarka", "psmirnov")
+	first.Duplicate = &DuplicateTable{Table: 0, Players: []string{"evgsol", "solarka", "psmirnov"}, Boards: boards}
+	second := newTestRoom("pavel", "olga", "ivan")
+	second.Duplicate = &DuplicateTable{Table: 1, Players: []string{"pavel", "olga", "ivan"}, Boards: boards}
+
+	positions := map[string]map[int]int{}
+	// next are the players playing right after evgsol.
+	next := map[string]bool{}
+	for range boards {
+		require.NoError(t, first.Shuffle("evgsol"))
+		require.NoError(t, second.Shuffle("pavel"))
+		require.Equal(t, first.Game.Dealer, second.Game.Dealer)
+		for j := range first.Duplicate.Players {
+			index := first.PlayerSideIndex(first.Duplicate.Players[j])
+			assert.Equal(t, index, second.PlayerSideIndex(second.Duplicate.Players[j]))
+			assert.Equal(t, first.Sides[index].Cards, second.Sides[index].Cards)
+
+			position := -1
+			for k, i := range first.Game.Players {
+				if i == index {
+					position = k
+				}
+			}
+			if positions[first.Sides[index].Name] == nil {
+				positions[first.Sides[index].Name] = map[int]int{}
+			}
+			positions[first.Sides[index].Name][position]++
+		}
+		players := first.Game.Players
+		for k, i := range players {
+			if first.Sides[i].Name == "evgsol" {
+				next[first.Sides[players[(k+1)%len(players)]].Name] = true
+			}
+		}
+		first.Game.Finished = true
+		second.Game.Finished = true
+	}
+
+	for _, name := range first.Duplicate.Players {
+		assert.Len(t, positions[name], 3, name)
+		for _, count := range positions[name] {
+			assert.Equal(t, 2, count, name)
+		}
+	}
+	assert.Equal(t, map[string]bool{"solarka": true, "psmirnov": true}, next)
+}
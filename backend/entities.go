@@ -1,6 +1,8 @@
 package main
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -87,12 +89,17 @@ type RoomSideInfo struct {
 	Cards  []Card `json:"cards" bson:"cards"`
 	Tricks int    `json:"tricks" bson:"tricks"`
 	Open   bool   `json:"open" bson:"open"`
+	Bot    string `json:"bot,omitempty" bson:"bot,omitempty"`
 }
 
 type RoomView struct {
-	ID      string   `json:"id"`
-	Players []string `json:"players"`
-	Status  string   `json:"status"`
+	ID         string   `json:"id"`
+	Players    []string `json:"players"`
+	Status     string   `json:"status"`
+	Spectators int      `json:"spectators"`
+	MaxPlayers int      `json:"maxPlayers"`
+	Password   bool     `json:"password"`
+	Locked     bool     `json:"locked"`
 }
 
 type RoomID primitive.ObjectID
@@ -137,13 +144,49 @@ func (id RoomID) IsZero() bool {
 }
 
 type Room struct {
-	ID           RoomID           `json:"id" bson:"_id"`
-	Sides        []RoomSideInfo   `json:"sides" bson:"sides"`
-	Center       []CenterCardInfo `json:"center" bson:"center"`
-	LastTrick    []CenterCardInfo `json:"lastTrick" bson:"lastTrick"`
-	Status       RoomStatus       `json:"status" bson:"status"`
-	PlayersCount int              `json:"playersCount" bson:"playersCount"`
-	BuypackIndex int              `json:"buypackIndex" bson:"buypackIndex"`
+	ID           RoomID            `json:"id" bson:"_id"`
+	Sides        []RoomSideInfo    `json:"sides" bson:"sides"`
+	Center       []CenterCardInfo  `json:"center" bson:"center"`
+	LastTrick    []CenterCardInfo  `json:"lastTrick" bson:"lastTrick"`
+	Status       RoomStatus        `json:"status" bson:"status"`
+	PlayersCount int               `json:"playersCount" bson:"playersCount"`
+	BuypackIndex int               `json:"buypackIndex" bson:"buypackIndex"`
+	CurrentSeed  *DealSeed         `json:"currentSeed,omitempty" bson:"currentSeed,omitempty"`
+	NextSeed     *DealSeed         `json:"nextSeed,omitempty" bson:"nextSeed,omitempty"`
+	LastReveal   *DealReveal       `json:"lastReveal,omitempty" bson:"lastReveal,omitempty"`
+	Game         *Game             `json:"game,omitempty" bson:"game,omitempty"`
+	Score        *ScoreSheet       `json:"score,omitempty" bson:"score,omitempty"`
+	Practice     bool              `json:"practice,omitempty" bson:"practice,omitempty"`
+	Hints        []string          `json:"hints,omitempty" bson:"hints,omitempty"`
+	Hint         *HandEstimate     `json:"hint,omitempty" bson:"-"`
+	Puzzle       *PuzzleAttempt    `json:"puzzle,omitempty" bson:"puzzle,omitempty"`
+	Spectators   []string          `json:"spectators,omitempty" bson:"spectators,omitempty"`
+	NoSpectators bool              `json:"noSpectators,omitempty" bson:"noSpectators,omitempty"`
+	Host         string            `json:"host,omitempty" bson:"host,omitempty"`
+	Hosted       bool              `json:"-" bson:"hosted,omitempty"`
+	Tournament   bool              `json:"tournament,omitempty" bson:"tournament,omitempty"`
+	Kibitzers    []string          `json:"kibitzers,omitempty" bson:"kibitzers,omitempty"`
+	KibitzDelay  *KibitzDelay      `json:"kibitzDelay,omitempty" bson:"kibitzDelay,omitempty"`
+	Events       []GameEvent       `json:"-" bson:"events,omitempty"`
+	Settings     *RoomSettings     `json:"settings,omitempty" bson:"settings,omitempty"`
+	Swap         *SeatSwap         `json:"swap,omitempty" bson:"swap,omitempty"`
+	Seats        []int             `json:"seats,omitempty" bson:"-"`
+	Locked       bool              `json:"locked,omitempty" bson:"locked,omitempty"`
+	HostLog      []HostAction      `json:"hostLog,omitempty" bson:"hostLog,omitempty"`
+	Away         *Absence          `json:"away,omitempty" bson:"away,omitempty"`
+	Clocks       []int             `json:"clocks,omitempty" bson:"clocks,omitempty"`
+	Clock        *ClockView        `json:"clock,omitempty" bson:"-"`
+	AutoPlay     []string          `json:"autoPlay,omitempty" bson:"autoPlay,omitempty"`
+	PreMoves     []PreMove         `json:"-" bson:"preMoves,omitempty"`
+	PreMove      *Card             `json:"preMove,omitempty" bson:"-"`
+	Proposal     *Proposal         `json:"proposal,omitempty" bson:"proposal,omitempty"`
+	PausedAt     *time.Time        `json:"pausedAt,omitempty" bson:"pausedAt,omitempty"`
+	History      []ProposalOutcome `json:"history,omitempty" bson:"history,omitempty"`
+	Muted        []string          `json:"muted,omitempty" bson:"muted,omitempty"`
+	Duplicate    *DuplicateTable   `json:"duplicate,omitempty" bson:"duplicate,omitempty"`
+	Chat         []ChatMessage     `json:"chat,omitempty" bson:"-"`
+	YourTurn     DecisionKind      `json:"yourTurn,omitempty" bson:"-"`
+	Bids         []Bid             `json:"legalBids,omitempty" bson:"-"`
 }
 
 func (r Room) ToView() RoomView {
@@ -155,12 +198,16 @@ func (r Room) ToView() RoomView {
 	}
 
 	res := RoomView{
-		ID:      r.ID.String(),
-		Players: players,
-		Status:  "playing",
+		ID:         r.ID.String(),
+		Players:    players,
+		Status:     "playing",
+		Spectators: len(r.Spectators),
+		MaxPlayers: r.MaxPlayers(),
+		Password:   r.HasPassword(),
+		Locked:     r.Locked,
 	}
 
-	if r.Status == RoomStatusCreated && r.PlayersCount < 4 {
+	if r.Status == RoomStatusCreated && r.PlayersCount < r.MaxPlayers() && !r.Locked {
 		res.Status = "available"
 	}
 
@@ -177,11 +224,58 @@ func (r *Room) PlayerSideIndex(playerName string) int {
 	return -1
 }
 
+// HideCards replaces the cards the player is not allowed to see, with
+// EMPTY_SIDE only the open hands stay visible as for the spectators.
+func (r *Room) HideCards(playerName string) {
+	for i := range r.Sides {
+		if (r.Sides[i].Name == playerName && playerName != EMPTY_SIDE) || r.Sides[i].Open {
+			continue
+		}
+		cards := make([]Card, len(r.Sides[i].Cards))
+		for j := range cards {
+			cards[j] = UnknownCard
+		}
+		r.Sides[i].Cards = cards
+	}
+
+	if r.Game != nil {
+		game := *r.Game
+		game.Hands, game.Buypack, game.Dropped = nil, nil, nil
+		r.Game = &game
+	}
+}
+
+// WantsHint tells whether the player gets a bidding hint now.
+func (r *Room) WantsHint(playerName string) bool {
+	if !r.Practice || r.Game == nil || r.Status != RoomStatusReady {
+		return false
+	}
+
+	index := r.PlayerSideIndex(playerName)
+	if index == -1 || len(r.Sides[index].Cards) != 10 {
+		return false
+	}
+
+	for _, name := range r.Hints {
+		if name == playerName {
+			return true
+		}
+	}
+
+	return false
+}
+
 const EMPTY_SIDE = ""
 
 type User struct {
-	Email          string `bson:"email"`
-	EmailConfirmed bool   `bson:"emailConfirmed"`
-	Login          string `bson:"_id"`
-	PasswordHash   []byte `bson:"pass"`
+	Email          string         `bson:"email"`
+	EmailConfirmed bool           `bson:"emailConfirmed"`
+	Login          string         `bson:"_id"`
+	PasswordHash   []byte         `bson:"pass"`
+	Bot            bool           `bson:"bot,omitempty"`
+	Owner          string         `bson:"owner,omitempty"`
+	APIKeyHashes   []string       `bson:"apiKeys,omitempty"`
+	Rating         float64        `bson:"rating,omitempty"`
+	RatedPulkas    int            `bson:"ratedPulkas,omitempty"`
+	RatingHistory  []RatingChange `bson:"ratingHistory,omitempty"`
 }
@@ -0,0 +1,864 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const SuitNoTrump Suit = "NT"
+
+var BidSuits = []Suit{SuitSpades, SuitClubs, SuitDiamonds, SuitHearts, SuitNoTrump}
+
+// Bid is either "pass", "misere" or a level followed by a suit: "6S", "7NT".
+type Bid string
+
+const (
+	BidPass   Bid = "pass"
+	BidMisere Bid = "misere"
+)
+
+func NewBid(level int, trump Suit) Bid {
+	return Bid(fmt.Sprintf("%d%s", level, trump))
+}
+
+// AllBids lists all the bids but pass in ascending order.
+var AllBids = func() []Bid {
+	var res []Bid
+	for level := 6; level <= 10; level++ {
+		if level == 9 {
+			res = append(res, BidMisere)
+		}
+		for _, s := range BidSuits {
+			res = append(res, NewBid(level, s))
+		}
+	}
+	return res
+}()
+
+func (b Bid) IsPass() bool {
+	return b == BidPass
+}
+
+func (b Bid) IsMisere() bool {
+	return b == BidMisere
+}
+
+// Level returns the number of tricks the bid promises, misere counts as 10.
+func (b Bid) Level() int {
+	if b.IsMisere() {
+		return 10
+	}
+
+	digits := strings.TrimRightFunc(string(b), func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	level, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0
+	}
+	return level
+}
+
+func (b Bid) Trump() Suit {
+	if b.IsMisere() || b.IsPass() {
+		return SuitNoTrump
+	}
+
+	return Suit(strings.TrimLeftFunc(string(b), func(r rune) bool {
+		return r >= '0' && r <= '9'
+	}))
+}
+
+func (b Bid) order() int {
+	for i, other := range AllBids {
+		if b == other {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (b Bid) Valid() bool {
+	return b.IsPass() || b.order() >= 0
+}
+
+type DecisionKind string
+
+const (
+	DecisionNone        DecisionKind = ""
+	DecisionShuffle     DecisionKind = "shuffle"
+	DecisionBid         DecisionKind = "bid"
+	DecisionTakeBuypack DecisionKind = "takeBuypack"
+	DecisionDrop        DecisionKind = "drop"
+	DecisionDeclare     DecisionKind = "declare"
+	DecisionWhist       DecisionKind = "whist"
+	DecisionMove        DecisionKind = "move"
+)
+
+type BidInfo struct {
+	Player string `json:"player" bson:"player"`
+	Bid    Bid    `json:"bid" bson:"bid"`
+}
+
+type WhistInfo struct {
+	Player string `json:"player" bson:"player"`
+	Whist  bool   `json:"whist" bson:"whist"`
+}
+
+// Game is the rules state of the current deal. Rooms without it are played
+// by hand: nothing is validated and tricks are taken manually.
+type Game struct {
+	Dealer   int         `json:"dealer" bson:"dealer"`
+	Players  []int       `json:"players" bson:"players"`
+	Turn     int         `json:"turn" bson:"turn"`
+	Bids     []BidInfo   `json:"bids" bson:"bids"`
+	Declarer int         `json:"declarer" bson:"declarer"`
+	Contract Bid         `json:"contract" bson:"contract"`
+	Whists   []WhistInfo `json:"whists" bson:"whists"`
+	Finished bool        `json:"finished" bson:"finished"`
+
+	DecisionSince time.Time `json:"decisionSince" bson:"decisionSince"`
+
+	// The record of the deal for the archive, hidden from the players.
+	Hands    [][]Card         `json:"-" bson:"hands"`
+	Buypack  []Card           `json:"-" bson:"buypack"`
+	Dropped  []Card           `json:"-" bson:"dropped"`
+	Plays    []CenterCardInfo `json:"-" bson:"plays"`
+	Balances []float64        `json:"-" bson:"balances,omitempty"`
+	Archived bool             `json:"-" bson:"archived"`
+}
+
+func (g *Game) nextPlayer(index int) int {
+	for i, p := range g.Players {
+		if p == index {
+			return g.Players[(i+1)%len(g.Players)]
+		}
+	}
+
+	return -1
+}
+
+func (g *Game) FirstHand() int {
+	return g.Players[0]
+}
+
+func (g *Game) passed(playerName string) bool {
+	for _, b := range g.Bids {
+		if b.Player == playerName && b.Bid.IsPass() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *Game) hasBid(playerName string) bool {
+	for _, b := range g.Bids {
+		if b.Player == playerName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *Game) HighestBid() Bid {
+	res := Bid("")
+	for _, b := range g.Bids {
+		if !b.Bid.IsPass() && b.Bid.order() > res.order() {
+			res = b.Bid
+		}
+	}
+
+	return res
+}
+
+func (g *Game) WhistingDone() bool {
+	return g.Contract.IsMisere() || len(g.Whists) == 2
+}
+
+func (g *Game) Whisters() []string {
+	var res []string
+	for _, w := range g.Whists {
+		if w.Whist {
+			res = append(res, w.Player)
+		}
+	}
+
+	return res
+}
+
+// NextDealer returns the first occupied side after the current dealer.
+func (r *Room) NextDealer() int {
+	for i := 1; i <= len(r.Sides); i++ {
+		index := (r.Game.Dealer + i) % len(r.Sides)
+		if r.Sides[index].Name != EMPTY_SIDE {
+			return index
+		}
+	}
+
+	return r.Game.Dealer
+}
+
+func (r *Room) playerIndex(playerName string) (int, error) {
+	index := r.PlayerSideIndex(playerName)
+	if index == -1 {
+		return -1, errors.New("wrong player name")
+	}
+
+	return index, nil
+}
+
+func (r *Room) checkTurn(playerName string, kind DecisionKind) (int, error) {
+	playerIndex, err := r.playerIndex(playerName)
+	if err != nil {
+		return -1, err
+	}
+
+	index, expected := r.Decision()
+	if expected != kind {
+		return -1, errors.New("wrong room status")
+	}
+
+	if index != playerIndex {
+		return -1, errors.New("not your turn")
+	}
+
+	return playerIndex, nil
+}
+
+// Decision returns the side which should act now and the kind of action.
+func (r *Room) Decision() (int, DecisionKind) {
+	if r.Game == nil || r.Away != nil || r.PausedAt != nil {
+		return -1, DecisionNone
+	}
+
+	if r.Game.Finished {
+		if r.Puzzle != nil || (r.Score != nil && r.Score.Finished) {
+			return -1, DecisionNone
+		}
+		return r.NextDealer(), DecisionShuffle
+	}
+
+	switch r.Status {
+	case RoomStatusReady:
+		return r.Game.Turn, DecisionBid
+	case RoomStatusBuypackOpened:
+		return r.Game.Declarer, DecisionTakeBuypack
+	case RoomStatusBuypackTaken:
+		return r.Game.Declarer, DecisionDrop
+	case RoomStatusPlaying:
+		if r.Game.Contract == "" {
+			return r.Game.Declarer, DecisionDeclare
+		}
+		if !r.Game.WhistingDone() {
+			return r.Game.Turn, DecisionWhist
+		}
+		return r.Game.Turn, DecisionMove
+	case RoomStatusAllPass:
+		return r.Game.Turn, DecisionMove
+	}
+
+	return -1, DecisionNone
+}
+
+// SetTurn shows the player the decision due from the player, with the bids
+// to choose from.
+func (r *Room) SetTurn(playerName string) {
+	index, kind := r.Decision()
+	if kind == DecisionNone || r.Sides[index].Name != playerName {
+		return
+	}
+
+	r.YourTurn = kind
+	switch kind {
+	case DecisionBid:
+		r.Bids = r.LegalBids(index)
+	case DecisionDeclare:
+		r.Bids = r.LegalContracts()
+	}
+}
+
+// Deal shuffles the cards from the room's next seed and starts a new game.
+func (r *Room) Deal(dealer int) error {
+	if r.PlayersCount < 3 || r.PlayersCount > 4 {
+		return errors.New("wrong players count")
+	}
+
+	var err error
+	if r.NextSeed == nil {
+		if r.NextSeed, err = NewDealSeed(); err != nil {
+			return err
+		}
+	}
+
+	buypackIndex := 0
+	var playersIndexes []int
+	if r.PlayersCount == 3 {
+		for i := 0; i < 4; i++ {
+			index := (dealer + i) % 4
+			if r.Sides[index].Name == EMPTY_SIDE {
+				buypackIndex = index
+			} else {
+				playersIndexes = append(playersIndexes, index)
+			}
+		}
+	} else {
+		buypackIndex = dealer
+		playersIndexes = []int{(dealer + 1) % 4, (dealer + 2) % 4, (dealer + 3) % 4}
+	}
+
+	if r.CurrentSeed != nil {
+		reveal := r.CurrentSeed.Reveal()
+		r.LastReveal = &reveal
+	}
+	seed := r.NextSeed
+	seed.BuypackIndex = buypackIndex
+	seed.PlayersIndexes = playersIndexes
+	r.CurrentSeed = seed
+	if r.NextSeed, err = NewDealSeed(); err != nil {
+		return err
+	}
+
+	hands := DealCards(ShuffledDeck(CombineSeeds(seed.ServerSeed, seed.Entropy)), buypackIndex, playersIndexes)
+
+	r.Status = RoomStatusReady
+	r.PreMoves = nil
+	r.Sides[buypackIndex].Cards = hands[buypackIndex]
+	r.Sides[buypackIndex].Tricks = 0
+	r.Sides[buypackIndex].Open = false
+	r.Center = nil
+	r.BuypackIndex = buypackIndex
+	r.LastTrick = []CenterCardInfo{}
+	for _, index := range playersIndexes {
+		r.Sides[index].Cards = hands[index]
+		r.Sides[index].Tricks = 0
+		r.Sides[index].Open = false
+	}
+
+	// The first hand sits right after the dealer.
+	players := playersIndexes
+	if r.PlayersCount == 3 {
+		players = []int{playersIndexes[1], playersIndexes[2], playersIndexes[0]}
+	}
+	if r.Score == nil {
+		var names []string
+		for _, side := range r.Sides {
+			if side.Name != EMPTY_SIDE {
+				names = append(names, side.Name)
+			}
+		}
+		r.Score = NewScoreSheet(names, r.Convention(), DefaultPulkaSize)
+	}
+
+	r.Game = &Game{
+		Dealer:   dealer,
+		Players:  players,
+		Turn:     players[0],
+		Bids:     []BidInfo{},
+		Declarer: -1,
+		Whists:   []WhistInfo{},
+		Buypack:  hands[buypackIndex],
+	}
+	for _, index := range players {
+		r.Game.Hands = append(r.Game.Hands, hands[index])
+	}
+	r.recordDeal()
+
+	return nil
+}
+
+func (r *Room) LegalBids(playerIndex int) []Bid {
+	if r.Game == nil || r.Status != RoomStatusReady || r.Game.Turn != playerIndex {
+		return nil
+	}
+
+	return r.openBids(playerIndex)
+}
+
+// openBids are the bids the player may still make in the auction, whoever's
+// turn it is.
+func (r *Room) openBids(playerIndex int) []Bid {
+	playerName := r.Sides[playerIndex].Name
+	highest := r.Game.HighestBid().order()
+	firstBid := !r.Game.hasBid(playerName)
+
+	res := []Bid{BidPass}
+	for _, b := range AllBids {
+		if b.order() <= highest {
+			continue
+		}
+		if b.IsMisere() && !firstBid {
+			continue
+		}
+		res = append(res, b)
+	}
+
+	return res
+}
+
+func (r *Room) MakeBid(playerName string, bid Bid) error {
+	playerIndex, err := r.checkTurn(playerName, DecisionBid)
+	if err != nil {
+		return err
+	}
+
+	if !containsBid(r.LegalBids(playerIndex), bid) {
+		return errors.New("illegal bid")
+	}
+
+	r.Game.Bids = append(r.Game.Bids, BidInfo{
+		Player: playerName,
+		Bid:    bid,
+	})
+
+	var active []int
+	for _, index := range r.Game.Players {
+		if !r.Game.passed(r.Sides[index].Name) {
+			active = append(active, index)
+		}
+	}
+
+	if len(active) == 0 {
+		r.startAllPass()
+		return nil
+	}
+
+	if len(active) == 1 && r.Game.hasBid(r.Sides[active[0]].Name) {
+		r.Game.Declarer = active[0]
+		r.Game.Turn = active[0]
+		r.Status = RoomStatusBuypackOpened
+		r.Sides[r.BuypackIndex].Open = true
+		return nil
+	}
+
+	next := r.Game.nextPlayer(playerIndex)
+	for r.Game.passed(r.Sides[next].Name) {
+		next = r.Game.nextPlayer(next)
+	}
+	r.Game.Turn = next
+
+	return nil
+}
+
+func (r *Room) startAllPass() {
+	buypack := r.Sides[r.BuypackIndex].Cards
+	r.Status = RoomStatusAllPass
+	r.Center = []CenterCardInfo{{
+		Card:   buypack[0],
+		Player: r.Sides[r.BuypackIndex].Name,
+	}}
+	r.Sides[r.BuypackIndex].Cards = buypack[1:]
+	r.Game.Turn = r.Game.FirstHand()
+}
+
+func (r *Room) TakeBuypack(playerName string) error {
+	playerIndex, err := r.checkTurn(playerName, DecisionTakeBuypack)
+	if err != nil {
+		return err
+	}
+
+	cards := append(append([]Card{}, r.Sides[playerIndex].Cards...), r.Sides[r.BuypackIndex].Cards...)
+	sort.Slice(cards, func(l, r int) bool {
+		return cards[l].Less(cards[r])
+	})
+
+	r.Sides[playerIndex].Cards = cards
+	r.Sides[r.BuypackIndex].Cards = []Card{}
+	r.Status = RoomStatusBuypackTaken
+	return nil
+}
+
+func (r *Room) Drop(playerName string, indexes []int) error {
+	playerIndex, err := r.checkTurn(playerName, DecisionDrop)
+	if err != nil {
+		return err
+	}
+
+	cards := r.Sides[playerIndex].Cards
+	if len(cards) != 12 {
+		return errors.New("wrong player cards length")
+	}
+
+	if len(indexes) != 2 || indexes[0] == indexes[1] {
+		return errors.New("wrong number of indexes")
+	}
+
+	newCards := []Card{}
+	for i, c := range cards {
+		if i != indexes[0] && i != indexes[1] {
+			newCards = append(newCards, c)
+		}
+	}
+	if len(newCards) != 10 {
+		return errors.New("wrong indexes")
+	}
+
+	r.Sides[playerIndex].Cards = newCards
+	r.Game.Dropped = []Card{cards[indexes[0]], cards[indexes[1]]}
+	r.Status = RoomStatusPlaying
+	return nil
+}
+
+func (r *Room) LegalContracts() []Bid {
+	if r.Game == nil || r.Game.Declarer == -1 {
+		return nil
+	}
+
+	highest := r.Game.HighestBid()
+	if highest.IsMisere() {
+		return []Bid{BidMisere}
+	}
+
+	var res []Bid
+	for _, b := range AllBids {
+		if !b.IsMisere() && b.order() >= highest.order() {
+			res = append(res, b)
+		}
+	}
+
+	return res
+}
+
+func (r *Room) Declare(playerName string, contract Bid) error {
+	if _, err := r.checkTurn(playerName, DecisionDeclare); err != nil {
+		return err
+	}
+
+	if !containsBid(r.LegalContracts(), contract) {
+		return errors.New("illegal contract")
+	}
+
+	r.Game.Contract = contract
+	if contract.IsMisere() {
+		r.Game.Turn = r.Game.FirstHand()
+	} else {
+		r.Game.Turn = r.Game.nextPlayer(r.Game.Declarer)
+	}
+
+	return nil
+}
+
+func (r *Room) MakeWhist(playerName string, whist bool) error {
+	playerIndex, err := r.checkTurn(playerName, DecisionWhist)
+	if err != nil {
+		return err
+	}
+
+	r.Game.Whists = append(r.Game.Whists, WhistInfo{
+		Player: playerName,
+		Whist:  whist,
+	})
+
+	if !r.Game.WhistingDone() {
+		r.Game.Turn = r.Game.nextPlayer(playerIndex)
+		return nil
+	}
+
+	if len(r.Game.Whisters()) == 0 {
+		r.finishGame()
+		return nil
+	}
+
+	r.Game.Turn = r.Game.FirstHand()
+	return nil
+}
+
+// Trump returns the trump suit of the current deal or SuitNoTrump.
+func (r *Room) Trump() Suit {
+	if r.Game == nil || r.Status == RoomStatusAllPass {
+		return SuitNoTrump
+	}
+
+	return r.Game.Contract.Trump()
+}
+
+func (r *Room) isPlayerCard(c CenterCardInfo) bool {
+	for _, index := range r.Game.Players {
+		if r.Sides[index].Name == c.Player {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Room) LegalMoves(playerIndex int) []int {
+	if r.Game == nil {
+		return nil
+	}
+
+	if index, kind := r.Decision(); kind != DecisionMove || index != playerIndex {
+		return nil
+	}
+
+	return LegalCards(r.Sides[playerIndex].Cards, r.Center, r.Trump())
+}
+
+// LegalCards returns indexes of the hand cards which may be played to the
+// center: the led suit must be followed, otherwise a trump must be played.
+func LegalCards(hand []Card, center []CenterCardInfo, trump Suit) []int {
+	var all, follow, trumps []int
+	for i, c := range hand {
+		all = append(all, i)
+		if len(center) > 0 && c.Suit == center[0].Card.Suit {
+			follow = append(follow, i)
+		}
+		if c.Suit == trump {
+			trumps = append(trumps, i)
+		}
+	}
+
+	if len(center) == 0 {
+		return all
+	}
+	if len(follow) > 0 {
+		return follow
+	}
+	if len(trumps) > 0 {
+		return trumps
+	}
+	return all
+}
+
+// TrickWinner returns the position in the center of the card taking the
+// trick. Cards of players are selected by the mask, the buypack card only
+// sets the suit.
+func TrickWinner(center []CenterCardInfo, isPlayer func(CenterCardInfo) bool, trump Suit) int {
+	best := -1
+	beats := func(c, other Card) bool {
+		if c.Suit == other.Suit {
+			return c.rankNumber() > other.rankNumber()
+		}
+		return c.Suit == trump
+	}
+
+	suit := center[0].Card.Suit
+	for i, c := range center {
+		if !isPlayer(c) {
+			continue
+		}
+		if best == -1 {
+			if c.Card.Suit == suit || c.Card.Suit == trump {
+				best = i
+			}
+			continue
+		}
+		if beats(c.Card, center[best].Card) {
+			best = i
+		}
+	}
+
+	if best != -1 {
+		return best
+	}
+
+	// Nobody followed the buypack card: the first player's card leads.
+	for i, c := range center {
+		if !isPlayer(c) {
+			continue
+		}
+		if best == -1 || (c.Card.Suit == center[best].Card.Suit && beats(c.Card, center[best].Card)) {
+			best = i
+		}
+	}
+
+	return best
+}
+
+func (r *Room) Move(playerName string, index int) error {
+	playerIndex, err := r.checkTurn(playerName, DecisionMove)
+	if err != nil {
+		return err
+	}
+
+	legal := false
+	for _, i := range r.LegalMoves(playerIndex) {
+		if i == index {
+			legal = true
+		}
+	}
+	if !legal {
+		return errors.New("illegal move")
+	}
+
+	cards := r.Sides[playerIndex].Cards
+	played := CenterCardInfo{
+		Card:   cards[index],
+		Player: playerName,
+	}
+	r.Center = append(r.Center, played)
+	r.Game.Plays = append(r.Game.Plays, played)
+	r.Sides[playerIndex].Cards = append(append([]Card{}, cards[:index]...), cards[index+1:]...)
+
+	count := 0
+	for _, c := range r.Center {
+		if r.isPlayerCard(c) {
+			count++
+		}
+	}
+
+	if count < len(r.Game.Players) {
+		r.Game.Turn = r.Game.nextPlayer(playerIndex)
+		return nil
+	}
+
+	r.takeTrick()
+	return nil
+}
+
+func (r *Room) takeTrick() {
+	winner := r.Center[TrickWinner(r.Center, r.isPlayerCard, r.Trump())]
+	winnerIndex := r.PlayerSideIndex(winner.Player)
+
+	r.Sides[winnerIndex].Tricks++
+	r.LastTrick = r.Center
+	r.Center = []CenterCardInfo{}
+	r.Game.Turn = winnerIndex
+
+	buypack := r.Sides[r.BuypackIndex].Cards
+	if r.Status == RoomStatusAllPass && len(buypack) > 0 {
+		r.Center = []CenterCardInfo{{
+			Card:   buypack[0],
+			Player: r.Sides[r.BuypackIndex].Name,
+		}}
+		r.Sides[r.BuypackIndex].Cards = buypack[1:]
+		r.Game.Turn = r.Game.FirstHand()
+	}
+
+	for _, index := range r.Game.Players {
+		if len(r.Sides[index].Cards) > 0 {
+			return
+		}
+	}
+
+	r.finishGame()
+}
+
+func (r *Room) finishGame() {
+	r.Game.Finished = true
+	r.Game.Turn = -1
+	if r.Score != nil {
+		res := r.DealResult()
+		before := r.Score.Balances()
+		r.Score.Record(res)
+		after := r.Score.Balances()
+		for _, name := range res.Players {
+			i := r.Score.index(name)
+			r.Game.Balances = append(r.Game.Balances, after[i]-before[i])
+		}
+	}
+	if r.Puzzle != nil {
+		r.Puzzle.finish(r)
+	}
+	if r.CurrentSeed != nil {
+		reveal := r.CurrentSeed.Reveal()
+		r.LastReveal = &reveal
+	}
+}
+
+// Shuffle deals the next game. The first deal is made by the player who asks
+// for it, then the dealer moves around the table.
+func (r *Room) Shuffle(playerName string) error {
+	dealer, err := r.playerIndex(playerName)
+	if err != nil {
+		return err
+	}
+
+	if r.Puzzle != nil {
+		return errors.New("puzzle rooms are not dealt")
+	}
+
+	if r.Game != nil {
+		if !r.Game.Finished {
+			return errors.New("deal is not finished")
+		}
+		if r.Score != nil && r.Score.Finished {
+			if r.Settings != nil && r.Settings.SinglePulka {
+				return errors.New("pulka is finished")
+			}
+			// A new pulka is dealt by the one who asks for it.
+			r.Score = nil
+			r.resetClocks()
+		} else {
+			dealer = r.NextDealer()
+		}
+	} else {
+		r.resetClocks()
+	}
+
+	if r.Duplicate != nil {
+		if dealer, err = r.nextBoard(); err != nil {
+			return err
+		}
+	}
+
+	return r.Deal(dealer)
+}
+
+// Apply performs the decision of the player, see Decision.
+func (r *Room) Apply(playerName string, kind DecisionKind, action Action) error {
+	if r.Away != nil || r.PausedAt != nil {
+		return errors.New("game is paused")
+	}
+
+	tricks := r.tricksTaken()
+	index, decision := r.Decision()
+	if err := r.apply(playerName, kind, action); err != nil {
+		return err
+	}
+	if timed(kind) && decision == kind {
+		r.chargeClock(index, time.Now())
+	}
+	if kind == DecisionMove {
+		r.dropPreMove(playerName)
+	}
+
+	// Deals are recorded by Deal itself.
+	if kind != DecisionShuffle {
+		r.record(GameEvent{
+			Player: playerName,
+			Kind:   kind,
+			Action: action,
+			Trick:  r.tricksTaken() > tricks,
+		})
+	}
+
+	return nil
+}
+
+func (r *Room) apply(playerName string, kind DecisionKind, action Action) error {
+	switch kind {
+	case DecisionShuffle:
+		return r.Shuffle(playerName)
+	case DecisionBid:
+		return r.MakeBid(playerName, action.Bid)
+	case DecisionTakeBuypack:
+		return r.TakeBuypack(playerName)
+	case DecisionDrop:
+		return r.Drop(playerName, action.Indexes)
+	case DecisionDeclare:
+		return r.Declare(playerName, action.Bid)
+	case DecisionWhist:
+		return r.MakeWhist(playerName, action.Whist)
+	case DecisionMove:
+		return r.Move(playerName, action.Index)
+	}
+
+	return errors.New("unknown decision")
+}
+
+func containsBid(bids []Bid, bid Bid) bool {
+	for _, b := range bids {
+		if b == bid {
+			return true
+		}
+	}
+
+	return false
+}
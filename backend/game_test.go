@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRoom(names ...string) *Room {
+	room := &Room{
+		Sides:  []RoomSideInfo{{}, {}, {}, {}},
+		Status: RoomStatusReady,
+	}
+	for i, name := range names {
+		room.Sides[i].Name = name
+		room.PlayersCount++
+	}
+
+	return room
+}
+
+func TestBidOrder(t *testing.T) {
+	assert.Equal(t, Bid("6S"), AllBids[0])
+	assert.Equal(t, Bid("10NT"), AllBids[len(AllBids)-1])
+	assert.True(t, Bid("8NT").order() < BidMisere.order())
+	assert.True(t, BidMisere.order() < Bid("9S").order())
+	assert.Equal(t, 7, Bid("7H").Level())
+	assert.Equal(t, SuitHearts, Bid("7H").Trump())
+	assert.Equal(t, SuitNoTrump, Bid("10NT").Trump())
+	assert.False(t, Bid("5S").Valid())
+}
+
+func TestTrickWinner(t *testing.T) {
+	all := func(CenterCardInfo) bool { return true }
+	center := []CenterCardInfo{
+		{Card: Card{SuitSpades, "10"}, Player: "a"},
+		{Card: Card{SuitSpades, "A"}, Player: "b"},
+		{Card: Card{SuitHearts, "7"}, Player: "c"},
+	}
+
+	assert.Equal(t, 1, TrickWinner(center, all, SuitNoTrump))
+	assert.Equal(t, 2, TrickWinner(center, all, SuitHearts))
+
+	buypack := func(c CenterCardInfo) bool { return c.Player != "" }
+	center = []CenterCardInfo{
+		{Card: Card{SuitClubs, "A"}, Player: ""},
+		{Card: Card{SuitSpades, "8"}, Player: "a"},
+		{Card: Card{SuitSpades, "J"}, Player: "b"},
+		{Card: Card{SuitHearts, "A"}, Player: "c"},
+	}
+	assert.Equal(t, 2, TrickWinner(center, buypack, SuitNoTrump))
+}
+
+func TestAuction(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	require.NoError(t, room.Shuffle("evgsol"))
+
+	require.Equal(t, []int{1, 2, 0}, room.Game.Players)
+	index, kind := room.Decision()
+	require.Equal(t, DecisionBid, kind)
+	require.Equal(t, 1, index)
+
+	room.SetTurn("evgsol")
+	assert.Empty(t, room.YourTurn)
+	room.SetTurn("solarka")
+	assert.Equal(t, DecisionBid, room.YourTurn)
+	assert.Equal(t, room.LegalBids(1), room.Bids)
+
+	require.Error(t, room.MakeBid("evgsol", "6S"))
+	require.NoError(t, room.MakeBid("solarka", "6S"))
+	require.Error(t, room.MakeBid("psmirnov", "6S"))
+	require.NoError(t, room.MakeBid("psmirnov", "misere"))
+	require.NoError(t, room.MakeBid("evgsol", "pass"))
+	require.NotContains(t, room.LegalBids(1), BidMisere)
+	require.NoError(t, room.MakeBid("solarka", "9S"))
+	require.NoError(t, room.MakeBid("psmirnov", "pass"))
+
+	index, kind = room.Decision()
+	assert.Equal(t, DecisionTakeBuypack, kind)
+	assert.Equal(t, 1, index)
+	assert.Equal(t, RoomStatusBuypackOpened, room.Status)
+	assert.Equal(t, []Bid{"9S", "9C", "9D", "9H", "9NT", "10S", "10C", "10D", "10H", "10NT"}, room.LegalContracts())
+}
+
+func TestAllPass(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov", "miracle")
+	require.NoError(t, room.Shuffle("evgsol"))
+
+	assert.Equal(t, 0, room.BuypackIndex)
+	for _, name := range []string{"solarka", "psmirnov", "miracle"} {
+		require.NoError(t, room.MakeBid(name, BidPass))
+	}
+
+	assert.Equal(t, RoomStatusAllPass, room.Status)
+	assert.Len(t, room.Center, 1)
+	assert.Len(t, room.Sides[0].Cards, 1)
+	index, kind := room.Decision()
+	assert.Equal(t, DecisionMove, kind)
+	assert.Equal(t, 1, index)
+}
+
+func TestBotsPlayDeals(t *testing.T) {
+	strategies := []Strategy{&HeuristicStrategy{}, NewRandomStrategy(1), &HeuristicStrategy{}}
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	require.NoError(t, room.Shuffle("evgsol"))
+
+	deals := 0
+	for deals < 50 {
+		index, kind := room.Decision()
+		if kind == DecisionNone {
+			require.True(t, room.Score.Finished)
+			require.NoError(t, room.Shuffle("evgsol"))
+			continue
+		}
+
+		if kind == DecisionShuffle {
+			tricks := 0
+			for _, i := range room.Game.Players {
+				tricks += room.Sides[i].Tricks
+			}
+			if room.Game.Contract == "" || len(room.Game.Whisters()) > 0 {
+				assert.Equal(t, 10, tricks)
+			}
+			deals++
+		}
+
+		action := strategies[index].Decide(room.ViewFor(index))
+		require.NoError(t, room.Apply(room.Sides[index].Name, kind, action), "%s %+v", kind, action)
+	}
+}
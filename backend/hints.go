@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+const (
+	HintSamples    = 12
+	HintConfidence = 0.5
+	MaxCachedHints = 1024
+)
+
+type BidChance struct {
+	Bid         Bid     `json:"bid"`
+	Probability float64 `json:"probability"`
+}
+
+// HandEstimate is the double dummy outcome of a hand over sampled hands of
+// the opponents, the buypack is not counted. Misere is the share of the
+// samples without a trick.
+type HandEstimate struct {
+	Samples   int              `json:"samples"`
+	Tricks    map[Suit]float64 `json:"tricks"`
+	Misere    float64          `json:"misere"`
+	Bids      []BidChance      `json:"bids"`
+	Suggested Bid              `json:"suggested"`
+}
+
+// EstimateHand samples the unseen cards, the samples depend on the hand only
+// so the estimate of the same hand does not change.
+func EstimateHand(hand []Card, samples int) (*HandEstimate, error) {
+	if len(hand) != 10 {
+		return nil, fmt.Errorf("wrong hand length %d", len(hand))
+	}
+
+	res := &HandEstimate{
+		Samples:   samples,
+		Tricks:    map[Suit]float64{},
+		Suggested: BidPass,
+	}
+	made := map[Bid]int{}
+	clean := 0
+
+	for i := 0; i < samples; i++ {
+		unseen := removeCards(ShuffledDeck([]byte(fmt.Sprintf("hint-%v-%d", hand, i))), hand)
+		pos := DDPosition{
+			Hands:  [][]Card{hand, unseen[:10], unseen[10:20]},
+			Leader: i % 3,
+		}
+
+		for _, trump := range BidSuits {
+			pos.Trump = trump
+			tricks, err := SolveTricks(pos, 0, false)
+			if err != nil {
+				return nil, err
+			}
+			res.Tricks[trump] += float64(tricks)
+			for level := 6; level <= tricks; level++ {
+				made[NewBid(level, trump)]++
+			}
+		}
+
+		pos.Trump = SuitNoTrump
+		tricks, err := SolveTricks(pos, 0, true)
+		if err != nil {
+			return nil, err
+		}
+		if tricks == 0 {
+			clean++
+			made[BidMisere]++
+		}
+	}
+
+	for trump := range res.Tricks {
+		res.Tricks[trump] /= float64(samples)
+	}
+	res.Misere = float64(clean) / float64(samples)
+
+	for _, bid := range AllBids {
+		if made[bid] == 0 {
+			continue
+		}
+		p := float64(made[bid]) / float64(samples)
+		res.Bids = append(res.Bids, BidChance{Bid: bid, Probability: p})
+		if p >= HintConfidence {
+			res.Suggested = bid
+		}
+	}
+
+	return res, nil
+}
+
+// Suggest returns a copy of the estimate suggesting the highest of the
+// legal bids made often enough, pass if there is none.
+func (e *HandEstimate) Suggest(legal []Bid) *HandEstimate {
+	res := *e
+	res.Suggested = BidPass
+	for _, chance := range e.Bids {
+		if chance.Probability >= HintConfidence && containsBid(legal, chance.Bid) {
+			res.Suggested = chance.Bid
+		}
+	}
+
+	return &res
+}
+
+// HintCache keeps the estimates of the hands, it is dropped when full.
+type HintCache struct {
+	mutex     sync.Mutex
+	estimates map[string]*HandEstimate
+	pending   map[string]bool
+}
+
+func NewHintCache() *HintCache {
+	return &HintCache{
+		estimates: map[string]*HandEstimate{},
+		pending:   map[string]bool{},
+	}
+}
+
+// Lookup returns the cached estimate of the hand. A missing one is computed
+// in the background and nil is returned, so the room polls do not wait for
+// the sampling and get the estimate once it is ready.
+func (c *HintCache) Lookup(hand []Card) *HandEstimate {
+	key := fmt.Sprint(hand)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if res, ok := c.estimates[key]; ok {
+		return res
+	}
+	if c.pending[key] {
+		return nil
+	}
+
+	c.pending[key] = true
+	hand = append([]Card(nil), hand...)
+	go func() {
+		res, err := EstimateHand(hand, HintSamples)
+		if err != nil {
+			log.Println(err)
+		}
+
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		delete(c.pending, key)
+		if res != nil {
+			c.store(key, res)
+		}
+	}()
+
+	return nil
+}
+
+func (c *HintCache) store(key string, res *HandEstimate) {
+	if len(c.estimates) >= MaxCachedHints {
+		c.estimates = map[string]*HandEstimate{}
+	}
+	c.estimates[key] = res
+}
+
+func (c *HintCache) Estimate(hand []Card) (*HandEstimate, error) {
+	key := fmt.Sprint(hand)
+
+	c.mutex.Lock()
+	res, ok := c.estimates[key]
+	c.mutex.Unlock()
+	if ok {
+		return res, nil
+	}
+
+	res, err := EstimateHand(hand, HintSamples)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.store(key, res)
+	c.mutex.Unlock()
+
+	return res, nil
+}
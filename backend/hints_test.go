@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateHand(t *testing.T) {
+	strong := parseCards("AS", "KS", "QS", "JS", "10S", "9S", "AH", "KH", "AD", "AC")
+	estimate, err := EstimateHand(strong, HintSamples)
+	require.NoError(t, err)
+	assert.True(t, estimate.Tricks[SuitSpades] >= 9)
+	assert.Equal(t, 0.0, estimate.Misere)
+	assert.Equal(t, 10, estimate.Suggested.Level())
+	assert.Contains(t, estimate.Bids, BidChance{Bid: "10S", Probability: 1})
+
+	low := parseCards("7S", "8S", "7C", "8C", "9C", "7D", "8D", "7H", "8H", "9H")
+	estimate, err = EstimateHand(low, HintSamples)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, estimate.Misere)
+	assert.Equal(t, BidMisere, estimate.Suggested)
+
+	_, err = EstimateHand(low[:9], HintSamples)
+	assert.Error(t, err)
+
+	cache := NewHintCache()
+	first, err := cache.Estimate(low)
+	require.NoError(t, err)
+	second, err := cache.Estimate(low)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Same(t, first, cache.Lookup(low))
+
+	assert.Nil(t, cache.Lookup(strong))
+	require.Eventually(t, func() bool {
+		return cache.Lookup(strong) != nil
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 10, cache.Lookup(strong).Suggested.Level())
+}
+
+func TestWantsHint(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	require.NoError(t, room.Shuffle("evgsol"))
+	room.Hints = []string{"solarka"}
+	assert.False(t, room.WantsHint("solarka"))
+
+	room.Practice = true
+	assert.True(t, room.WantsHint("solarka"))
+	assert.False(t, room.WantsHint("psmirnov"))
+}
+
+func TestSuggestLegalBid(t *testing.T) {
+	strong := parseCards("AS", "KS", "QS", "JS", "10S", "9S", "AH", "KH", "AD", "AC")
+	estimate, err := EstimateHand(strong, HintSamples)
+	require.NoError(t, err)
+
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	require.NoError(t, room.Shuffle("evgsol"))
+	first := room.Game.Turn
+	assert.Equal(t, estimate.Suggested, estimate.Suggest(room.openBids(first)).Suggested)
+
+	require.NoError(t, room.MakeBid(room.Sides[first].Name, "9NT"))
+	second := room.Game.Turn
+	suggested := estimate.Suggest(room.openBids(second)).Suggested
+	assert.True(t, containsBid(room.LegalBids(second), suggested))
+	assert.True(t, suggested == BidPass || suggested.order() > Bid("9NT").order())
+	assert.Equal(t, 10, estimate.Suggested.Level())
+}
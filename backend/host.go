@@ -0,0 +1,95 @@
+package main
+
+import "time"
+
+// MaxHostLog is the number of the last host actions kept in a room.
+const MaxHostLog = 50
+
+const (
+	HostActionHost         = "host"
+	HostActionKick         = "kick"
+	HostActionLock         = "lock"
+	HostActionUnlock       = "unlock"
+	HostActionSettings     = "settings"
+	HostActionInvite       = "invite"
+	HostActionKibitzers    = "kibitzers"
+	HostActionMute         = "mute"
+	HostActionUnmute       = "unmute"
+	HostActionSpectators   = "spectators"
+	HostActionNoSpectators = "noSpectators"
+)
+
+// HostAction is an entry of the room's host log, Player is the one the
+// action is applied to.
+type HostAction struct {
+	Time   time.Time `json:"time" bson:"time"`
+	Host   string    `json:"host" bson:"host"`
+	Action string    `json:"action" bson:"action"`
+	Player string    `json:"player,omitempty" bson:"player,omitempty"`
+}
+
+func (r *Room) logHost(host, action, player string) {
+	r.HostLog = append(r.HostLog, HostAction{
+		Time:   time.Now(),
+		Host:   host,
+		Action: action,
+		Player: player,
+	})
+	if len(r.HostLog) > MaxHostLog {
+		r.HostLog = append([]HostAction{}, r.HostLog[len(r.HostLog)-MaxHostLog:]...)
+	}
+}
+
+// PulkaStarted tells whether the players are bound to the table: from the
+// first deal until the pulka is finished.
+func (r *Room) PulkaStarted() bool {
+	if r.Status != RoomStatusCreated && r.Status != RoomStatusReady {
+		return true
+	}
+
+	return r.Game != nil && !(r.Game.Finished && r.Score != nil && r.Score.Finished)
+}
+
+// removePlayer frees the side and resets the room to gather the players
+// again.
+func (r *Room) removePlayer(index int) {
+	r.vacate(index)
+	r.Status = RoomStatusCreated
+	r.Game = nil
+	r.Score = nil
+}
+
+// hasHumans tells whether anybody but the bots is seated.
+func (r *Room) hasHumans() bool {
+	for _, side := range r.Sides {
+		if side.Name != EMPTY_SIDE && side.Bot == "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// vacate frees the side, the host passes to the next human player.
+func (r *Room) vacate(index int) {
+	playerName := r.Sides[index].Name
+
+	r.Sides[index].Name = EMPTY_SIDE
+	r.Sides[index].Bot = ""
+	r.Hints = removeName(r.Hints, playerName)
+	if r.Swap != nil && (r.Swap.From == playerName || r.Swap.To == playerName) {
+		r.Swap = nil
+	}
+	r.PlayersCount--
+	if r.Host == playerName {
+		r.Host = ""
+		r.Hosted = true
+		for _, side := range r.Sides {
+			if side.Name != EMPTY_SIDE && side.Bot == "" {
+				r.Host = side.Name
+				r.logHost(side.Name, HostActionHost, "")
+				break
+			}
+		}
+	}
+}
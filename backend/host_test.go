@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPulkaStarted(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	assert.False(t, room.PulkaStarted())
+
+	room.Host = "evgsol"
+	room.removePlayer(0)
+	assert.Equal(t, "solarka", room.Host)
+	assert.Equal(t, RoomStatusCreated, room.Status)
+	if assert.Len(t, room.HostLog, 1) {
+		assert.Equal(t, HostActionHost, room.HostLog[0].Action)
+	}
+
+	room = newTestRoom("evgsol", "solarka", "psmirnov")
+	assert.NoError(t, room.Shuffle("evgsol"))
+	assert.True(t, room.PulkaStarted())
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	MaxKibitzTricks  = 10
+	MaxKibitzSeconds = 600
+	// KeptDeals is the number of the last deals kept in the event log of
+	// a room, enough to show a delayed deal.
+	KeptDeals = 3
+)
+
+// GameEvent is an applied action of the room. The event of a deal keeps
+// the room as it was dealt, the following ones are replayed on it.
+type GameEvent struct {
+	Time   time.Time    `bson:"time"`
+	Player string       `bson:"player"`
+	Kind   DecisionKind `bson:"kind"`
+	Action Action       `bson:"action"`
+	Trick  bool         `bson:"trick"`
+	Deal   *Room        `bson:"deal,omitempty"`
+}
+
+func (r *Room) tricksTaken() int {
+	res := 0
+	for _, side := range r.Sides {
+		res += side.Tricks
+	}
+
+	return res
+}
+
+func (r *Room) record(event GameEvent) {
+	event.Time = time.Now()
+	r.Events = append(r.Events, event)
+}
+
+func (r *Room) recordDeal() {
+	deal := &Room{
+		ID:           r.ID,
+		Sides:        make([]RoomSideInfo, len(r.Sides)),
+		Status:       r.Status,
+		PlayersCount: r.PlayersCount,
+		BuypackIndex: r.BuypackIndex,
+		Center:       []CenterCardInfo{},
+		LastTrick:    []CenterCardInfo{},
+	}
+	for i, side := range r.Sides {
+		side.Cards = append([]Card{}, side.Cards...)
+		deal.Sides[i] = side
+	}
+	game := *r.Game
+	game.Bids, game.Whists, game.Plays = []BidInfo{}, []WhistInfo{}, nil
+	deal.Game = &game
+
+	deals := 0
+	for i := len(r.Events) - 1; i >= 0; i-- {
+		if r.Events[i].Deal == nil {
+			continue
+		}
+		deals++
+		if deals == KeptDeals {
+			r.Events = append([]GameEvent{}, r.Events[i:]...)
+			break
+		}
+	}
+
+	r.record(GameEvent{
+		Player: r.Sides[r.Game.Dealer].Name,
+		Kind:   DecisionShuffle,
+		Deal:   deal,
+	})
+}
+
+// IsHost tells whether the player manages the room. Rooms created before
+// the hosts are managed by any of their players, the later ones left without
+// a host and the tournament tables by nobody.
+func (r *Room) IsHost(playerName string) bool {
+	if r.Tournament {
+		return false
+	}
+	if r.Host == "" {
+		return !r.Hosted && r.PlayerSideIndex(playerName) != -1
+	}
+
+	return r.Host == playerName
+}
+
+// KibitzDelay holds the kibitzers behind the live play: an action is shown
+// when both the tricks have been taken and the seconds have passed after it.
+type KibitzDelay struct {
+	Tricks  int `json:"tricks" bson:"tricks"`
+	Seconds int `json:"seconds" bson:"seconds"`
+}
+
+func (d KibitzDelay) Valid() bool {
+	if d.Tricks < 0 || d.Tricks > MaxKibitzTricks || d.Seconds < 0 || d.Seconds > MaxKibitzSeconds {
+		return false
+	}
+
+	return d.Tricks > 0 || d.Seconds > 0
+}
+
+func (r *Room) IsKibitzer(name string) bool {
+	for _, k := range r.Kibitzers {
+		if k == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KibitzView is the delayed room with all the cards open. Behind counts the
+// actions not shown yet.
+type KibitzView struct {
+	Room   *Room       `json:"room"`
+	Delay  KibitzDelay `json:"delay"`
+	Behind int         `json:"behind"`
+}
+
+// KibitzView replays the event log up to the delay, Room is nil until the
+// first delayed deal.
+func (r *Room) KibitzView(now time.Time) (*KibitzView, error) {
+	if r.KibitzDelay == nil {
+		return nil, errors.New("kibitzers are not allowed")
+	}
+	delay := *r.KibitzDelay
+
+	shown := len(r.Events)
+	tricks := 0
+	for i := len(r.Events) - 1; i >= 0; i-- {
+		if tricks < delay.Tricks || now.Sub(r.Events[i].Time) < time.Duration(delay.Seconds)*time.Second {
+			shown = i
+		}
+		if r.Events[i].Trick {
+			tricks++
+		}
+	}
+
+	res := &KibitzView{
+		Delay:  delay,
+		Behind: len(r.Events) - shown,
+	}
+
+	start := -1
+	for i := 0; i < shown; i++ {
+		if r.Events[i].Deal != nil {
+			start = i
+		}
+	}
+	if start == -1 {
+		return res, nil
+	}
+
+	room := *r.Events[start].Deal
+	room.Sides = make([]RoomSideInfo, len(room.Sides))
+	for i, side := range r.Events[start].Deal.Sides {
+		side.Cards = append([]Card{}, side.Cards...)
+		room.Sides[i] = side
+	}
+	game := *room.Game
+	room.Game = &game
+
+	for _, event := range r.Events[start+1 : shown] {
+		if err := room.apply(event.Player, event.Kind, event.Action); err != nil {
+			return nil, err
+		}
+	}
+	room.Game.Hands, room.Game.Buypack, room.Game.Dropped = nil, nil, nil
+	res.Room = &room
+
+	return res, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKibitzView(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	strategy := &HeuristicStrategy{}
+	require.NoError(t, room.Shuffle("evgsol"))
+
+	_, err := room.KibitzView(time.Now())
+	assert.Error(t, err)
+
+	room.KibitzDelay = &KibitzDelay{Seconds: 60}
+	view, err := room.KibitzView(time.Now())
+	require.NoError(t, err)
+	assert.Nil(t, view.Room)
+	assert.Equal(t, 1, view.Behind)
+
+	checked := 0
+	for deals := 0; deals < 3; {
+		index, kind := room.Decision()
+		if kind == DecisionShuffle {
+			deals++
+		}
+		require.NoError(t, room.Apply(room.Sides[index].Name, kind, strategy.Decide(room.ViewFor(index))))
+
+		room.KibitzDelay = &KibitzDelay{Seconds: 60}
+		view, err := room.KibitzView(time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.NotNil(t, view.Room)
+		assert.Equal(t, 0, view.Behind)
+		assert.Equal(t, room.Status, view.Room.Status)
+		for i := range room.Sides {
+			assert.Equal(t, room.Sides[i].Cards, view.Room.Sides[i].Cards)
+		}
+
+		if (room.Status == RoomStatusPlaying || room.Status == RoomStatusAllPass) && room.tricksTaken() >= 2 && !room.Game.Finished {
+			room.KibitzDelay = &KibitzDelay{Tricks: 2}
+			view, err = room.KibitzView(time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, room.tricksTaken()-2, view.Room.tricksTaken())
+			assert.True(t, view.Behind > 0)
+			checked++
+		}
+	}
+	assert.True(t, checked > 0)
+	assert.True(t, len(room.Events) < 3*40)
+}
+
+func TestIsHost(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka")
+	assert.True(t, room.IsHost("solarka"))
+	assert.False(t, room.IsHost("psmirnov"))
+
+	room.Host, room.Hosted = "evgsol", true
+	assert.True(t, room.IsHost("evgsol"))
+	assert.False(t, room.IsHost("solarka"))
+
+	room.Sides[1].Bot = "heuristic"
+	room.vacate(0)
+	assert.Empty(t, room.Host)
+	assert.False(t, room.IsHost("solarka"))
+
+	table := newTestRoom("evgsol", "solarka", "psmirnov")
+	table.Tournament = true
+	assert.False(t, table.IsHost("evgsol"))
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -93,6 +95,102 @@ func (m *LoginManager) Register(request *http.Request) (interface{}, error) {
 	return nil, nil
 }
 
+type CreateBotRequest struct {
+	Login string `json:"login"`
+}
+
+type CreateBotResponse struct {
+	Login  string `json:"login"`
+	APIKey string `json:"apiKey"`
+}
+
+func (m *LoginManager) CreateBot(request *http.Request, playerName string) (interface{}, error) {
+	var input CreateBotRequest
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		return nil, err
+	}
+
+	key, err := m.userManager.CreateBot(request.Context(), playerName, input.Login)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateBotResponse{
+		Login:  input.Login,
+		APIKey: key,
+	}, nil
+}
+
+func (m *LoginManager) RotateBotKey(request *http.Request, playerName string) (interface{}, error) {
+	var input CreateBotRequest
+	if err := json.NewDecoder(request.Body).Decode(&input); err != nil {
+		return nil, err
+	}
+
+	key, err := m.userManager.RotateAPIKey(request.Context(), playerName, input.Login)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateBotResponse{
+		Login:  input.Login,
+		APIKey: key,
+	}, nil
+}
+
+func (m *LoginManager) Leaderboard(request *http.Request, playerName string) (interface{}, error) {
+	return m.userManager.Leaderboard(request.Context())
+}
+
+// Rating returns the rating with the history of the given player, the
+// user's own one by default.
+func (m *LoginManager) Rating(request *http.Request, playerName string) (interface{}, error) {
+	if name := request.URL.Query().Get("player"); name != "" {
+		playerName = name
+	}
+
+	return m.userManager.Rating(request.Context(), playerName)
+}
+
+type authContextKey struct{}
+
+// IsAPIKeyRequest tells if the request was authorized by a bot API key.
+func IsAPIKeyRequest(r *http.Request) bool {
+	viaKey, _ := r.Context().Value(authContextKey{}).(bool)
+	return viaKey
+}
+
+// AuthRequired accepts "Authorization: Bearer <api key>" of bot accounts
+// and falls back to the token cookie.
+func (m *LoginManager) AuthRequired(f func(*http.Request, string) (interface{}, error)) func(*http.Request) (interface{}, error) {
+	cookieRequired := loginRequired(f)
+	return func(r *http.Request) (interface{}, error) {
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			return cookieRequired(r)
+		}
+
+		login, err := m.userManager.CheckAPIKey(r.Context(), strings.TrimPrefix(header, "Bearer "))
+		if err != nil {
+			return nil, err
+		}
+
+		return f(r.WithContext(context.WithValue(r.Context(), authContextKey{}, true)), login)
+	}
+}
+
+// AuthOptional passes an empty login to the anonymous requests.
+func (m *LoginManager) AuthOptional(f func(*http.Request, string) (interface{}, error)) func(*http.Request) (interface{}, error) {
+	authRequired := m.AuthRequired(f)
+	return func(r *http.Request) (interface{}, error) {
+		if _, err := r.Cookie("token"); err == http.ErrNoCookie && r.Header.Get("Authorization") == "" {
+			return f(r, "")
+		}
+
+		return authRequired(r)
+	}
+}
+
 func loginRequired(f func(*http.Request, string) (interface{}, error)) func(*http.Request) (interface{}, error) {
 	return func(r *http.Request) (interface{}, error) {
 		c, err := r.Cookie("token")
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"log"
-	"math/rand"
 	"net/http"
 	"os"
 	"time"
@@ -19,12 +19,17 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if err := Config.Init(CONFIGFILE); err != nil {
 		log.Fatal(err)
 	}
 
-	rand.Seed(time.Now().UnixNano())
-
 	session, err := mgo.Dial(Config.MongoURL)
 	if err != nil {
 		log.Fatal(err)
@@ -32,31 +37,98 @@ func main() {
 
 	roomDAO := NewRoomDAO(session)
 	roomManager := NewRoomManager(roomDAO)
+	chatFilter, err := NewChatFilter(Config.ChatFilter)
+	if err != nil {
+		log.Fatal(err)
+	}
+	roomManager.SetChatFilter(chatFilter)
 	userDAO := NewUserDAO(session)
 	userManager := NewUserManager(userDAO)
 	loginManager := NewLoginManager(userManager)
+	roomManager.SetUsers(userManager)
 	controller := NewController(roomManager)
+	auth := func(f func(*http.Request, string) (interface{}, error)) func(*http.Request) (interface{}, error) {
+		return loginManager.AuthRequired(controller.Track(f))
+	}
+
+	go NewBotDriver(roomManager).Watch(context.Background(), time.Second, Config.BotDecisionTime())
+	go controller.matchmaker.Watch(context.Background(), time.Second)
+	go controller.tournaments.Watch(context.Background(), 5*time.Second)
 
 	mux := http.NewServeMux()
 
 	mux.Handle("/login", handlers.LoggingHandler(os.Stdout, http.HandlerFunc(loginManager.Login)))
 	mux.Handle("/register", handlers.LoggingHandler(os.Stdout, decorate(loginManager.Register)))
 
-	mux.Handle("/room", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.Room))))
-	mux.Handle("/shuffle", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.Shuffle))))
-	mux.Handle("/openBuypack", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.OpenBuypack))))
-	mux.Handle("/takeBuypack", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.TakeBuypack))))
-	mux.Handle("/drop", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.Drop))))
-	mux.Handle("/move", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.Move))))
-	mux.Handle("/takeTrick", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.TakeTrick))))
-	mux.Handle("/allPass", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.AllPass))))
-	mux.Handle("/changeVisibility", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.ChangeVisibility))))
+	mux.Handle("/room", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Room))))
+	mux.Handle("/shuffle", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Shuffle))))
+	mux.Handle("/openBuypack", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.OpenBuypack))))
+	mux.Handle("/takeBuypack", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.TakeBuypack))))
+	mux.Handle("/drop", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Drop))))
+	mux.Handle("/move", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Move))))
+	mux.Handle("/takeTrick", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.TakeTrick))))
+	mux.Handle("/allPass", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.AllPass))))
+	mux.Handle("/changeVisibility", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.ChangeVisibility))))
+	mux.Handle("/bid", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Bid))))
+	mux.Handle("/declare", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Declare))))
+	mux.Handle("/whist", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Whist))))
+	mux.Handle("/entropy", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Entropy))))
+	mux.Handle("/verifyDeal", handlers.LoggingHandler(os.Stdout, decorate(controller.VerifyDeal)))
+	mux.Handle("/odds", handlers.LoggingHandler(os.Stdout, decorate(controller.Odds)))
+	mux.Handle("/deals", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Deals))))
+	mux.Handle("/stats", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Stats))))
+	mux.Handle("/analysis", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Analysis))))
+	mux.Handle("/mistakes", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Mistakes))))
 
-	mux.Handle("/rooms", handlers.LoggingHandler(os.Stdout, decorate(controller.GetRooms)))
-	mux.Handle("/playerIn", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.PlayerIn))))
-	mux.Handle("/playerOut", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.PlayerOut))))
-	mux.Handle("/roomReady", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.RoomReady))))
-	mux.Handle("/createRoom", handlers.LoggingHandler(os.Stdout, decorate(loginRequired(controller.CreateRoom))))
+	mux.Handle("/rooms", handlers.LoggingHandler(os.Stdout, decorate(loginManager.AuthOptional(controller.GetRooms))))
+	mux.Handle("/playerIn", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.PlayerIn))))
+	mux.Handle("/invite", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Invite))))
+	mux.Handle("/swapSeats", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.SwapSeats))))
+	mux.Handle("/answerSwap", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.AnswerSwap))))
+	mux.Handle("/kick", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Kick))))
+	mux.Handle("/lock", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Lock))))
+	mux.Handle("/roomSettings", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.RoomSettings))))
+	mux.Handle("/closeRoom", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.CloseRoom))))
+	mux.Handle("/voteAbsence", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.VoteAbsence))))
+	mux.Handle("/autoPlay", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.AutoPlay))))
+	mux.Handle("/preMove", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.PreMove))))
+	mux.Handle("/propose", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Propose))))
+	mux.Handle("/voteProposal", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.VoteProposal))))
+	mux.Handle("/chat", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Chat))))
+	mux.Handle("/sendMessage", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.SendMessage))))
+	mux.Handle("/mute", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Mute))))
+	mux.Handle("/online", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Online))))
+	mux.Handle("/invitations", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Invitations))))
+	mux.Handle("/acceptInvitation", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.AcceptInvitation))))
+	mux.Handle("/declineInvitation", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.DeclineInvitation))))
+	mux.Handle("/findGame", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.FindGame))))
+	mux.Handle("/leaveQueue", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.LeaveQueue))))
+	mux.Handle("/createTournament", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.CreateTournament))))
+	mux.Handle("/registerTournament", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.RegisterTournament))))
+	mux.Handle("/startTournament", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.StartTournament))))
+	mux.Handle("/noShow", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.NoShow))))
+	mux.Handle("/tournament", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Tournament))))
+	mux.Handle("/queue", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Queue))))
+	mux.Handle("/playerOut", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.PlayerOut))))
+	mux.Handle("/roomReady", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.RoomReady))))
+	mux.Handle("/puzzles", handlers.LoggingHandler(os.Stdout, decorate(controller.Puzzles)))
+	mux.Handle("/startPuzzle", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.StartPuzzle))))
+	mux.Handle("/watch", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Watch))))
+	mux.Handle("/unwatch", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Unwatch))))
+	mux.Handle("/spectating", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Spectating))))
+	mux.Handle("/kibitzers", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Kibitzers))))
+	mux.Handle("/kibitz", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Kibitz))))
+	mux.Handle("/practice", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Practice))))
+	mux.Handle("/hints", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.Hints))))
+	mux.Handle("/addBot", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.AddBot))))
+	mux.Handle("/removeBot", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.RemoveBot))))
+	mux.Handle("/leaderboard", handlers.LoggingHandler(os.Stdout, decorate(loginManager.AuthOptional(loginManager.Leaderboard))))
+	mux.Handle("/rating", handlers.LoggingHandler(os.Stdout, decorate(auth(loginManager.Rating))))
+	mux.Handle("/createBot", handlers.LoggingHandler(os.Stdout, decorate(auth(loginManager.CreateBot))))
+	mux.Handle("/rotateBotKey", handlers.LoggingHandler(os.Stdout, decorate(auth(loginManager.RotateBotKey))))
+	mux.Handle("/bot/decision", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.BotDecision))))
+	mux.Handle("/bot/act", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.BotAct))))
+	mux.Handle("/createRoom", handlers.LoggingHandler(os.Stdout, decorate(auth(controller.CreateRoom))))
 
 	c := cors.New(cors.Options{
 		AllowedOrigins:   Config.Hostnames,
@@ -177,7 +177,8 @@ func TestRoomHandler(t *testing.T) {
             "status": 1,
             "lastTrick": [],
             "playersCount": 0,
-            "buypackIndex": 0
+            "buypackIndex": 0,
+            "seats": [0, 1, 2, 3]
         }`, stored.ID.String())
 
 	res, err := json.Marshal(result)
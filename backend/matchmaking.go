@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	MaxStake = 100
+	// RatingWindow is the rating spread of a table matched at once, it
+	// widens with the waiting time of the players.
+	RatingWindow          = 100
+	RatingWindowPerSecond = 5
+)
+
+// MatchTicket is a player waiting for a table of the given kind.
+type MatchTicket struct {
+	Player     string     `json:"player"`
+	Convention Convention `json:"convention"`
+	Stake      int        `json:"stake"`
+	Players    int        `json:"players"`
+	Rating     float64    `json:"rating"`
+	Since      time.Time  `json:"since"`
+}
+
+func (t MatchTicket) Valid() bool {
+	return t.Convention.Valid() && t.Stake >= 0 && t.Stake <= MaxStake && (t.Players == 3 || t.Players == 4)
+}
+
+func (t MatchTicket) compatible(other MatchTicket) bool {
+	return t.Convention == other.Convention && t.Stake == other.Stake && t.Players == other.Players
+}
+
+// QueueStatus is the ticket of the waiting player and the number of the
+// compatible players waiting with the player.
+type QueueStatus struct {
+	Ticket  *MatchTicket `json:"ticket"`
+	Waiting int          `json:"waiting"`
+}
+
+// Matchmaker seats the players of the queue at the new rooms.
+type Matchmaker struct {
+	roomManager *RoomManager
+
+	mutex   sync.Mutex
+	tickets []MatchTicket
+}
+
+func NewMatchmaker(roomManager *RoomManager) *Matchmaker {
+	return &Matchmaker{
+		roomManager: roomManager,
+	}
+}
+
+func (m *Matchmaker) Enqueue(ctx context.Context, ticket MatchTicket) error {
+	if !ticket.Valid() {
+		return errors.New("wrong ticket")
+	}
+
+	room, err := m.roomManager.GetOneForPlayer(ctx, ticket.Player)
+	if err != nil {
+		return err
+	}
+	if room != nil {
+		return errors.New("player is already in room")
+	}
+
+	m.mutex.Lock()
+	m.remove(ticket.Player)
+	m.tickets = append(m.tickets, ticket)
+	m.mutex.Unlock()
+
+	return m.Match(ctx, ticket.Since)
+}
+
+func (m *Matchmaker) Leave(playerName string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.remove(playerName) {
+		return errors.New("player is not in queue")
+	}
+
+	return nil
+}
+
+func (m *Matchmaker) remove(playerName string) bool {
+	for i, t := range m.tickets {
+		if t.Player == playerName {
+			m.tickets = append(m.tickets[:i:i], m.tickets[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Matchmaker) Status(playerName string) QueueStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var res QueueStatus
+	for i, t := range m.tickets {
+		if t.Player == playerName {
+			ticket := m.tickets[i]
+			res.Ticket = &ticket
+		}
+	}
+	if res.Ticket == nil {
+		return res
+	}
+
+	for _, t := range m.tickets {
+		if t.compatible(*res.Ticket) {
+			res.Waiting++
+		}
+	}
+
+	return res
+}
+
+// Match seats the matched tables. The players who have got into a room
+// meanwhile leave the queue, their partners keep waiting. The players of
+// a table failed to be seated are put back in the queue.
+func (m *Matchmaker) Match(ctx context.Context, now time.Time) error {
+	m.mutex.Lock()
+	var tables [][]MatchTicket
+	m.tickets, tables = MatchTables(m.tickets, now)
+	m.mutex.Unlock()
+
+	var res error
+	for _, table := range tables {
+		if err := m.seat(ctx, table); err != nil {
+			m.requeue(table)
+			if res == nil {
+				res = err
+			}
+		}
+	}
+
+	return res
+}
+
+func (m *Matchmaker) requeue(tickets []MatchTicket) {
+	m.mutex.Lock()
+	m.tickets = append(m.tickets, tickets...)
+	m.mutex.Unlock()
+}
+
+func (m *Matchmaker) seat(ctx context.Context, table []MatchTicket) error {
+	var free []MatchTicket
+	for _, t := range table {
+		room, err := m.roomManager.GetOneForPlayer(ctx, t.Player)
+		if err != nil {
+			return err
+		}
+		if room == nil {
+			free = append(free, t)
+		}
+	}
+
+	if len(free) < len(table) {
+		m.requeue(free)
+		return nil
+	}
+
+	settings, err := NewRoomSettings(false, "", table[0].Players)
+	if err != nil {
+		return err
+	}
+	settings.Convention = table[0].Convention
+	settings.Stake = table[0].Stake
+
+	names := make([]string, len(table))
+	for i, t := range table {
+		names[i] = t.Player
+	}
+
+	_, err = m.roomManager.CreateReadyRoom(ctx, names, settings)
+	return err
+}
+
+func (m *Matchmaker) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := m.Match(ctx, now); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+// MatchTables groups the compatible tickets into the tables of the closest
+// ratings within the window and returns the tickets left waiting.
+func MatchTables(tickets []MatchTicket, now time.Time) ([]MatchTicket, [][]MatchTicket) {
+	var rest []MatchTicket
+	var tables [][]MatchTicket
+
+	done := make([]bool, len(tickets))
+	for i := range tickets {
+		if done[i] {
+			continue
+		}
+		var group []MatchTicket
+		for j := i; j < len(tickets); j++ {
+			if !done[j] && tickets[j].compatible(tickets[i]) {
+				group = append(group, tickets[j])
+				done[j] = true
+			}
+		}
+		sort.SliceStable(group, func(a, b int) bool {
+			return group[a].Rating < group[b].Rating
+		})
+
+		size := group[0].Players
+		for len(group) >= size {
+			best := -1
+			for start := 0; start+size <= len(group); start++ {
+				window := group[start : start+size]
+				spread := window[size-1].Rating - window[0].Rating
+				if spread > ratingWindow(window, now) {
+					continue
+				}
+				if best == -1 || spread < group[best+size-1].Rating-group[best].Rating {
+					best = start
+				}
+			}
+			if best == -1 {
+				break
+			}
+			tables = append(tables, append([]MatchTicket{}, group[best:best+size]...))
+			group = append(group[:best:best], group[best+size:]...)
+		}
+		rest = append(rest, group...)
+	}
+
+	sort.SliceStable(rest, func(a, b int) bool {
+		return rest[a].Since.Before(rest[b].Since)
+	})
+
+	return rest, tables
+}
+
+// ratingWindow widens with the waiting time of the longest waiting player.
+func ratingWindow(window []MatchTicket, now time.Time) float64 {
+	var wait time.Duration
+	for _, t := range window {
+		if w := now.Sub(t.Since); w > wait {
+			wait = w
+		}
+	}
+
+	return RatingWindow + RatingWindowPerSecond*wait.Seconds()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchTables(t *testing.T) {
+	now := time.Now()
+	ticket := func(name string, rating float64, players int) MatchTicket {
+		return MatchTicket{Player: name, Convention: ConventionSochi, Players: players, Rating: rating, Since: now}
+	}
+
+	tickets := []MatchTicket{
+		ticket("evgsol", 1500, 3),
+		ticket("solarka", 1900, 3),
+		ticket("psmirnov", 1550, 3),
+		ticket("pavel", 1450, 3),
+		ticket("olga", 1500, 4),
+		ticket("ivan", 1880, 3),
+	}
+
+	rest, tables := MatchTables(tickets, now)
+	assert.Len(t, tables, 1)
+	assert.Equal(t, []string{"pavel", "evgsol", "psmirnov"}, []string{tables[0][0].Player, tables[0][1].Player, tables[0][2].Player})
+	assert.Len(t, rest, 3)
+
+	rest, tables = MatchTables(rest, now.Add(time.Minute))
+	assert.Empty(t, tables)
+	assert.Len(t, rest, 3)
+
+	rest = append(rest, ticket("maria", 1600, 3))
+	rest, tables = MatchTables(rest, now.Add(time.Minute))
+	assert.Len(t, tables, 1)
+	assert.Equal(t, []MatchTicket{ticket("olga", 1500, 4)}, rest)
+}
+
+func TestMatchTicket(t *testing.T) {
+	assert.True(t, MatchTicket{Convention: ConventionLeningrad, Players: 4}.Valid())
+	assert.False(t, MatchTicket{Convention: "paris", Players: 4}.Valid())
+	assert.False(t, MatchTicket{Convention: ConventionSochi, Players: 2}.Valid())
+	assert.False(t, MatchTicket{Convention: ConventionSochi, Players: 3, Stake: MaxStake + 1}.Valid())
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+)
+
+// DefaultHoldings are the unseen holdings of a fresh deal: the hands of the
+// opponents and the buypack.
+var DefaultHoldings = []int{10, 10, 2}
+
+// MaxHoldings are the hands of three opponents and the buypack, the splits
+// grow combinatorially with the holdings.
+const MaxHoldings = 4
+
+type SuitSplit struct {
+	Counts      []int   `json:"counts"`
+	Probability float64 `json:"probability"`
+	Fraction    string  `json:"fraction"`
+}
+
+type SuitOdds struct {
+	Suit   Suit        `json:"suit"`
+	Unseen int         `json:"unseen"`
+	Splits []SuitSplit `json:"splits"`
+}
+
+// Odds describes how the unseen cards may lie. CardChances is the chance of
+// any single unseen card to be in every holding.
+type Odds struct {
+	Unseen      []Card     `json:"unseen"`
+	Holdings    []int      `json:"holdings"`
+	CardChances []float64  `json:"cardChances"`
+	Suits       []SuitOdds `json:"suits"`
+}
+
+func binomial(n, k int) *big.Int {
+	return new(big.Int).Binomial(int64(n), int64(k))
+}
+
+// CalculateOdds returns the exact chances of the suit splits among the
+// holdings, every card not in hand or known being equally likely anywhere.
+func CalculateOdds(hand, known []Card, holdings []int) (*Odds, error) {
+	seen := map[Card]bool{}
+	for _, c := range append(append([]Card{}, hand...), known...) {
+		if c.suitNumber() >= len(AllSuits) || c.rankNumber() >= len(AllRanks) {
+			return nil, errors.New("unknown card")
+		}
+		if seen[c] {
+			return nil, errors.New("duplicate card")
+		}
+		seen[c] = true
+	}
+
+	res := &Odds{Unseen: []Card{}}
+	for _, c := range NewDeck() {
+		if !seen[c] {
+			res.Unseen = append(res.Unseen, c)
+		}
+	}
+	n := len(res.Unseen)
+
+	if holdings == nil {
+		holdings = DefaultHoldings
+	}
+	if len(holdings) > MaxHoldings {
+		return nil, errors.New("too many holdings")
+	}
+	total := 0
+	for _, h := range holdings {
+		if h < 0 {
+			return nil, errors.New("wrong holdings")
+		}
+		total += h
+	}
+	if total != n || len(holdings) == 0 {
+		return nil, errors.New("holdings do not match the unseen cards")
+	}
+	res.Holdings = holdings
+
+	for _, h := range holdings {
+		res.CardChances = append(res.CardChances, float64(h)/float64(n))
+	}
+
+	for _, suit := range AllSuits {
+		count := 0
+		for _, c := range res.Unseen {
+			if c.Suit == suit {
+				count++
+			}
+		}
+		odds := SuitOdds{Suit: suit, Unseen: count}
+
+		all := binomial(n, count)
+		counts := make([]int, len(holdings))
+		var split func(i, left int)
+		split = func(i, left int) {
+			if i == len(holdings)-1 {
+				if left > holdings[i] {
+					return
+				}
+				counts[i] = left
+				ways := big.NewInt(1)
+				for j, x := range counts {
+					ways.Mul(ways, binomial(holdings[j], x))
+				}
+				p := new(big.Rat).SetFrac(ways, all)
+				f, _ := p.Float64()
+				odds.Splits = append(odds.Splits, SuitSplit{
+					Counts:      append([]int{}, counts...),
+					Probability: f,
+					Fraction:    p.RatString(),
+				})
+				return
+			}
+			for x := 0; x <= left && x <= holdings[i]; x++ {
+				counts[i] = x
+				split(i+1, left-x)
+			}
+		}
+		split(0, count)
+
+		res.Suits = append(res.Suits, odds)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateOdds(t *testing.T) {
+	hand := parseCards("AS", "KS", "QS", "JS", "AH", "KH", "AD", "KD", "AC", "KC")
+	odds, err := CalculateOdds(hand, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, odds.Unseen, 22)
+	assert.InDelta(t, 2.0/22, odds.CardChances[2], 1e-9)
+
+	spades := odds.Suits[0]
+	assert.Equal(t, SuitSpades, spades.Suit)
+	assert.Equal(t, 4, spades.Unseen)
+	total := 0.0
+	for _, split := range spades.Splits {
+		total += split.Probability
+		if split.Counts[0] == 2 && split.Counts[1] == 2 {
+			assert.Equal(t, "405/1463", split.Fraction)
+		}
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+
+	known := parseCards("7S", "8S", "9S", "10S", "7H", "8H")
+	odds, err = CalculateOdds(hand, known, []int{7, 7, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []SuitSplit{{Counts: []int{0, 0, 0}, Probability: 1, Fraction: "1"}}, odds.Suits[0].Splits)
+
+	_, err = CalculateOdds(hand, known, nil)
+	assert.Error(t, err)
+	_, err = CalculateOdds(hand, hand[:1], nil)
+	assert.Error(t, err)
+	_, err = CalculateOdds(hand, nil, []int{5, 5, 5, 5, 2})
+	assert.Error(t, err)
+	_, err = CalculateOdds(append(parseCards("AS"), Card{Suit: "X", Rank: "A"}), nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package main
+
+import "errors"
+
+// PreMove is a card queued by the player to be played on the next turn.
+type PreMove struct {
+	Player string `bson:"player"`
+	Card   Card   `bson:"card"`
+}
+
+func (r *Room) PreMoveOf(playerName string) *Card {
+	for _, pm := range r.PreMoves {
+		if pm.Player == playerName {
+			card := pm.Card
+			return &card
+		}
+	}
+
+	return nil
+}
+
+func (r *Room) dropPreMove(playerName string) {
+	var res []PreMove
+	for _, pm := range r.PreMoves {
+		if pm.Player != playerName {
+			res = append(res, pm)
+		}
+	}
+	r.PreMoves = res
+}
+
+// QueuePreMove replaces the card queued by the player, nil clears it.
+func (r *Room) QueuePreMove(playerName string, card *Card) error {
+	index, err := r.playerIndex(playerName)
+	if err != nil {
+		return err
+	}
+
+	r.dropPreMove(playerName)
+	if card == nil {
+		return nil
+	}
+
+	if r.Game == nil || r.Game.Finished {
+		return errors.New("deal is finished")
+	}
+
+	if turn, kind := r.Decision(); kind == DecisionMove && turn == index {
+		return errors.New("it is your turn")
+	}
+
+	if cardIndex(r.Sides[index].Cards, *card) == -1 {
+		return errors.New("no such card")
+	}
+
+	r.PreMoves = append(r.PreMoves, PreMove{Player: playerName, Card: *card})
+	return nil
+}
+
+func cardIndex(cards []Card, card Card) int {
+	for i, c := range cards {
+		if c == card {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// QueuedMove returns the move made for the human player to move now: the
+// queued card if it is still legal, otherwise the forced move for the
+// players who asked to auto-play them. The queued card is dropped anyway.
+func (r *Room) QueuedMove() (int, Action, bool) {
+	index, kind := r.Decision()
+	if kind != DecisionMove || r.Sides[index].Bot != "" {
+		return -1, Action{}, false
+	}
+	name := r.Sides[index].Name
+	legal := r.LegalMoves(index)
+
+	if card := r.PreMoveOf(name); card != nil {
+		r.dropPreMove(name)
+		i := cardIndex(r.Sides[index].Cards, *card)
+		for _, l := range legal {
+			if l == i {
+				return index, Action{Index: i}, true
+			}
+		}
+	}
+
+	if containsName(r.AutoPlay, name) && r.forced(index, legal) {
+		return index, Action{Index: legal[0]}, true
+	}
+
+	return -1, Action{}, false
+}
+
+// forced tells whether the legal cards are all the same for the player: one
+// card or a sequence of a suit without the unplayed cards of the others
+// between.
+func (r *Room) forced(index int, legal []int) bool {
+	hand := r.Sides[index].Cards
+	if len(legal) == 1 {
+		return true
+	}
+
+	known := map[Card]bool{}
+	for _, c := range hand {
+		known[c] = true
+	}
+	for _, p := range r.Game.Plays {
+		known[p.Card] = true
+	}
+	for _, p := range r.Center {
+		known[p.Card] = true
+	}
+	if index == r.Game.Declarer {
+		for _, c := range r.Game.Dropped {
+			known[c] = true
+		}
+	}
+
+	suit := hand[legal[0]].Suit
+	low, high := len(AllRanks), -1
+	for _, i := range legal {
+		c := hand[i]
+		if c.Suit != suit {
+			return false
+		}
+		if c.rankNumber() < low {
+			low = c.rankNumber()
+		}
+		if c.rankNumber() > high {
+			high = c.rankNumber()
+		}
+	}
+
+	for rank := low + 1; rank < high; rank++ {
+		if !known[Card{Suit: suit, Rank: AllRanks[rank]}] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// playUntilMove plays the room with the heuristic bot until a move is due.
+func playUntilMove(t *testing.T, room *Room) int {
+	strategy := &HeuristicStrategy{}
+	for {
+		index, kind := room.Decision()
+		if kind == DecisionMove {
+			return index
+		}
+		require.NoError(t, room.Apply(room.Sides[index].Name, kind, strategy.Decide(room.ViewFor(index))))
+	}
+}
+
+func TestPreMove(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	require.NoError(t, room.Shuffle("evgsol"))
+	index := playUntilMove(t, room)
+	name := room.Sides[index].Name
+	assert.Error(t, room.QueuePreMove(name, &room.Sides[index].Cards[0]))
+
+	next := room.Game.nextPlayer(index)
+	nextName := room.Sides[next].Name
+	assert.Error(t, room.QueuePreMove(nextName, &room.Sides[index].Cards[0]))
+	card := room.Sides[next].Cards[0]
+	require.NoError(t, room.QueuePreMove(nextName, &card))
+	assert.Equal(t, &card, room.PreMoveOf(nextName))
+
+	require.NoError(t, room.Apply(name, DecisionMove, Action{Index: room.LegalMoves(index)[0]}))
+	legal := false
+	for _, i := range room.LegalMoves(next) {
+		legal = legal || room.Sides[next].Cards[i] == card
+	}
+
+	turn, action, ok := room.QueuedMove()
+	assert.Nil(t, room.PreMoveOf(nextName))
+	require.Equal(t, legal, ok)
+	if ok {
+		assert.Equal(t, next, turn)
+		assert.Equal(t, card, room.Sides[next].Cards[action.Index])
+	}
+}
+
+func TestForcedMove(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	require.NoError(t, room.Shuffle("evgsol"))
+	index := playUntilMove(t, room)
+	room.Game.Plays = nil
+	room.Center = []CenterCardInfo{{Card: Card{SuitSpades, "8"}, Player: "-"}}
+	room.Sides[index].Cards = []Card{{SuitSpades, "9"}, {SuitSpades, "10"}, {SuitSpades, "Q"}, {SuitHearts, "A"}}
+
+	assert.True(t, room.forced(index, []int{0, 1}))
+	assert.False(t, room.forced(index, []int{0, 1, 2}))
+	room.Game.Plays = []CenterCardInfo{{Card: Card{SuitSpades, "J"}}}
+	assert.True(t, room.forced(index, []int{0, 1, 2}))
+	assert.False(t, room.forced(index, []int{2, 3}))
+
+	_, _, ok := room.QueuedMove()
+	assert.False(t, ok)
+	room.AutoPlay = []string{room.Sides[index].Name}
+	turn, action, ok := room.QueuedMove()
+	require.True(t, ok)
+	assert.Equal(t, index, turn)
+	assert.Equal(t, Card{SuitSpades, "9"}, room.Sides[index].Cards[action.Index])
+}
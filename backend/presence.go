@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PresenceTimeout is the time a user stays online after the last request.
+const PresenceTimeout = time.Minute
+
+const (
+	PresenceIdle    = "idle"
+	PresenceInRoom  = "inRoom"
+	PresencePlaying = "playing"
+)
+
+// Presence keeps the time of the last request of every user.
+type Presence struct {
+	mutex sync.Mutex
+	seen  map[string]time.Time
+}
+
+func NewPresence() *Presence {
+	return &Presence{
+		seen: map[string]time.Time{},
+	}
+}
+
+func (p *Presence) See(name string, now time.Time) {
+	p.mutex.Lock()
+	p.seen[name] = now
+	p.mutex.Unlock()
+}
+
+// Online returns the users seen within the timeout sorted by name, the
+// others are forgotten.
+func (p *Presence) Online(now time.Time) []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var res []string
+	for name, seen := range p.seen {
+		if now.Sub(seen) > PresenceTimeout {
+			delete(p.seen, name)
+			continue
+		}
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// Track marks the user of every authorized request as online.
+func (p *Presence) Track(f func(*http.Request, string) (interface{}, error)) func(*http.Request, string) (interface{}, error) {
+	return func(r *http.Request, login string) (interface{}, error) {
+		p.See(login, time.Now())
+		return f(r, login)
+	}
+}
+
+type OnlinePlayer struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	RoomID string `json:"roomId,omitempty"`
+}
+
+type Invitation struct {
+	RoomID  string   `json:"roomId"`
+	Host    string   `json:"host"`
+	Players []string `json:"players"`
+}
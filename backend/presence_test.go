@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresence(t *testing.T) {
+	now := time.Now()
+	p := NewPresence()
+	p.See("solarka", now.Add(-2*PresenceTimeout))
+	p.See("psmirnov", now)
+
+	tracked := p.Track(func(*http.Request, string) (interface{}, error) { return nil, nil })
+	_, _ = tracked(httptest.NewRequest("GET", "/room", nil), "evgsol")
+
+	assert.Equal(t, []string{"evgsol", "psmirnov"}, p.Online(now))
+	assert.Empty(t, p.Online(now.Add(2*PresenceTimeout)))
+}
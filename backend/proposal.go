@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ProposalTimeout = time.Minute
+	// MaxHistory is the number of the last proposals kept in a room.
+	MaxHistory = 50
+)
+
+const (
+	ProposalPause  = "pause"
+	ProposalResume = "resume"
+	ProposalAbort  = "abort"
+)
+
+const (
+	OutcomeApproved = "approved"
+	OutcomeRejected = "rejected"
+	OutcomeExpired  = "expired"
+)
+
+// Proposal changes the room once all the active players approve it.
+type Proposal struct {
+	Kind     string    `json:"kind" bson:"kind"`
+	Proposer string    `json:"proposer" bson:"proposer"`
+	Approved []string  `json:"approved" bson:"approved"`
+	Expires  time.Time `json:"expires" bson:"expires"`
+}
+
+type ProposalOutcome struct {
+	Time     time.Time `json:"time" bson:"time"`
+	Kind     string    `json:"kind" bson:"kind"`
+	Proposer string    `json:"proposer" bson:"proposer"`
+	Outcome  string    `json:"outcome" bson:"outcome"`
+}
+
+func (r *Room) closeProposal(outcome string, now time.Time) {
+	r.History = append(r.History, ProposalOutcome{
+		Time:     now,
+		Kind:     r.Proposal.Kind,
+		Proposer: r.Proposal.Proposer,
+		Outcome:  outcome,
+	})
+	if len(r.History) > MaxHistory {
+		r.History = append([]ProposalOutcome{}, r.History[len(r.History)-MaxHistory:]...)
+	}
+	r.Proposal = nil
+}
+
+// ExpireProposal closes the proposal nobody has rejected in time.
+func (r *Room) ExpireProposal(now time.Time) {
+	if r.Proposal != nil && now.After(r.Proposal.Expires) {
+		r.closeProposal(OutcomeExpired, now)
+	}
+}
+
+func (r *Room) canApply(kind string) error {
+	switch kind {
+	case ProposalPause:
+		if r.Game == nil || r.Game.Finished || r.PausedAt != nil {
+			return errors.New("nothing to pause")
+		}
+	case ProposalResume:
+		if r.PausedAt == nil {
+			return errors.New("room is not paused")
+		}
+	case ProposalAbort:
+		if r.Score == nil || r.Score.Finished {
+			return errors.New("no pulka to abort")
+		}
+	default:
+		return errors.New("unknown proposal")
+	}
+
+	return nil
+}
+
+func (r *Room) Propose(playerName, kind string, now time.Time) error {
+	r.ExpireProposal(now)
+
+	if !containsName(r.voters(), playerName) {
+		return errors.New("player cannot vote")
+	}
+
+	if r.Proposal != nil {
+		return errors.New("another proposal is open")
+	}
+
+	if err := r.canApply(kind); err != nil {
+		return err
+	}
+
+	r.Proposal = &Proposal{
+		Kind:     kind,
+		Proposer: playerName,
+		Approved: []string{playerName},
+		Expires:  now.Add(ProposalTimeout),
+	}
+	r.settleProposal(now)
+
+	return nil
+}
+
+func (r *Room) VoteProposal(playerName string, approve bool, now time.Time) error {
+	r.ExpireProposal(now)
+
+	if r.Proposal == nil {
+		return errors.New("no proposal")
+	}
+
+	if !containsName(r.voters(), playerName) {
+		return errors.New("player cannot vote")
+	}
+
+	if !approve {
+		r.closeProposal(OutcomeRejected, now)
+		return nil
+	}
+
+	if !containsName(r.Proposal.Approved, playerName) {
+		r.Proposal.Approved = append(r.Proposal.Approved, playerName)
+	}
+	r.settleProposal(now)
+
+	return nil
+}
+
+// settleProposal applies the proposal approved by everyone.
+func (r *Room) settleProposal(now time.Time) {
+	for _, name := range r.voters() {
+		if !containsName(r.Proposal.Approved, name) {
+			return
+		}
+	}
+
+	if r.canApply(r.Proposal.Kind) != nil {
+		r.closeProposal(OutcomeRejected, now)
+		return
+	}
+
+	switch r.Proposal.Kind {
+	case ProposalPause:
+		r.PausedAt = &now
+	case ProposalResume:
+		if r.Game != nil {
+			r.Game.DecisionSince = r.Game.DecisionSince.Add(now.Sub(*r.PausedAt))
+		}
+		r.PausedAt = nil
+	case ProposalAbort:
+		r.PausedAt = nil
+		r.Score.Finished = true
+		r.Score.Aborted = true
+		if r.Game != nil && !r.Game.Finished {
+			r.Game.Finished = true
+			r.Game.Turn = -1
+			r.Game.Archived = true
+		}
+	}
+	r.closeProposal(OutcomeApproved, now)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProposals(t *testing.T) {
+	now := time.Now()
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	room.Score = NewScoreSheet([]string{"evgsol", "solarka", "psmirnov"}, ConventionSochi, 10)
+	require.NoError(t, room.Shuffle("evgsol"))
+	room.Game.DecisionSince = now
+
+	assert.Error(t, room.Propose("evgsol", ProposalResume, now))
+	require.NoError(t, room.Propose("evgsol", ProposalPause, now))
+	assert.Error(t, room.Propose("solarka", ProposalAbort, now))
+	require.NoError(t, room.VoteProposal("solarka", true, now))
+	assert.Nil(t, room.PausedAt)
+	require.NoError(t, room.VoteProposal("psmirnov", true, now))
+	require.NotNil(t, room.PausedAt)
+	_, kind := room.Decision()
+	assert.Equal(t, DecisionNone, kind)
+
+	later := now.Add(10 * time.Minute)
+	require.NoError(t, room.Propose("solarka", ProposalResume, later))
+	assert.Error(t, room.VoteProposal("evgsol", true, later.Add(2*ProposalTimeout)))
+	assert.NotNil(t, room.PausedAt)
+
+	require.NoError(t, room.Propose("solarka", ProposalResume, later))
+	require.NoError(t, room.VoteProposal("evgsol", false, later))
+	require.NoError(t, room.Propose("solarka", ProposalResume, later))
+	require.NoError(t, room.VoteProposal("evgsol", true, later))
+	require.NoError(t, room.VoteProposal("psmirnov", true, later))
+	assert.Nil(t, room.PausedAt)
+	assert.Equal(t, now.Add(10*time.Minute), room.Game.DecisionSince)
+
+	require.NoError(t, room.Propose("psmirnov", ProposalAbort, later))
+	require.NoError(t, room.VoteProposal("evgsol", true, later))
+	require.NoError(t, room.VoteProposal("solarka", true, later))
+	assert.True(t, room.Score.Finished && room.Score.Aborted)
+	assert.True(t, room.Game.Finished)
+
+	var outcomes []string
+	for _, h := range room.History {
+		outcomes = append(outcomes, h.Kind+" "+h.Outcome)
+	}
+	assert.Equal(t, []string{
+		"pause approved",
+		"resume expired",
+		"resume rejected",
+		"resume approved",
+		"abort approved",
+	}, outcomes)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Puzzle is a deal with open cards to be played by one side against the
+// solver. Seat 0 is the first hand and leads, the hands are written as
+// "AS KS 10H". The declarer must take at least Target tricks, at most for
+// misere, and the player of a defender seat must prevent it.
+type Puzzle struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Hands       []string `json:"hands"`
+	Contract    Bid      `json:"contract"`
+	Declarer    int      `json:"declarer"`
+	Player      int      `json:"player"`
+	Target      int      `json:"target"`
+}
+
+type PuzzleAttempt struct {
+	ID       string `json:"id" bson:"id"`
+	Target   int    `json:"target" bson:"target"`
+	Defence  bool   `json:"defence" bson:"defence"`
+	Finished bool   `json:"finished" bson:"finished"`
+	Solved   bool   `json:"solved" bson:"solved"`
+}
+
+//go:embed puzzles.json
+var puzzlesData []byte
+
+var Puzzles = func() []Puzzle {
+	res, err := LoadPuzzles(puzzlesData)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}()
+
+func FindPuzzle(id string) (*Puzzle, error) {
+	for i := range Puzzles {
+		if Puzzles[i].ID == id {
+			return &Puzzles[i], nil
+		}
+	}
+
+	return nil, errors.New("unknown puzzle")
+}
+
+func ParseCards(s string) ([]Card, error) {
+	var res []Card
+	for _, field := range strings.Fields(s) {
+		c := Card{Suit: Suit(field[len(field)-1:]), Rank: field[:len(field)-1]}
+		if c.suitNumber() >= len(AllSuits) || c.rankNumber() >= len(AllRanks) {
+			return nil, fmt.Errorf("unknown card %q", field)
+		}
+		res = append(res, c)
+	}
+
+	return res, nil
+}
+
+func LoadPuzzles(data []byte) ([]Puzzle, error) {
+	var res []Puzzle
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+
+	for _, p := range res {
+		if _, err := p.Position(); err != nil {
+			return nil, fmt.Errorf("puzzle %s: %w", p.ID, err)
+		}
+	}
+
+	return res, nil
+}
+
+func (p *Puzzle) Position() (DDPosition, error) {
+	if len(p.Hands) != 3 {
+		return DDPosition{}, errors.New("wrong players count")
+	}
+	if p.Contract.IsPass() || !p.Contract.Valid() {
+		return DDPosition{}, errors.New("wrong contract")
+	}
+	if p.Declarer < 0 || p.Declarer > 2 || p.Player < 0 || p.Player > 2 {
+		return DDPosition{}, errors.New("wrong seat")
+	}
+
+	pos := DDPosition{Trump: p.Contract.Trump()}
+	for _, hand := range p.Hands {
+		cards, err := ParseCards(hand)
+		if err != nil {
+			return DDPosition{}, err
+		}
+		if len(pos.Hands) > 0 && len(cards) != len(pos.Hands[0]) {
+			return DDPosition{}, errors.New("hands of different length")
+		}
+		pos.Hands = append(pos.Hands, cards)
+	}
+	if p.Target < 0 || p.Target > len(pos.Hands[0]) {
+		return DDPosition{}, errors.New("wrong target")
+	}
+
+	// The solver also checks the cards are not repeated.
+	if _, err := newDDSolver(pos, 0, false); err != nil {
+		return DDPosition{}, err
+	}
+
+	return pos, nil
+}
+
+// Solvable tells whether the player's side reaches the target with the best
+// play against any defence.
+func (p *Puzzle) Solvable() (bool, error) {
+	pos, err := p.Position()
+	if err != nil {
+		return false, err
+	}
+
+	tricks, err := SolveTricks(pos, p.Declarer, p.Contract.IsMisere())
+	if err != nil {
+		return false, err
+	}
+
+	return p.made(tricks) == (p.Player == p.Declarer), nil
+}
+
+func (p *Puzzle) made(tricks int) bool {
+	if p.Contract.IsMisere() {
+		return tricks <= p.Target
+	}
+
+	return tricks >= p.Target
+}
+
+// NewPuzzleRoom seats the player at the puzzle's side and the solver bots at
+// the others, sides 0-2 are the seats and side 3 is the empty buypack.
+func NewPuzzleRoom(p *Puzzle, playerName string) (*Room, error) {
+	pos, err := p.Position()
+	if err != nil {
+		return nil, err
+	}
+
+	room := &Room{
+		ID:           NewRoomID(),
+		Sides:        make([]RoomSideInfo, 4),
+		Center:       []CenterCardInfo{},
+		LastTrick:    []CenterCardInfo{},
+		Status:       RoomStatusPlaying,
+		PlayersCount: 3,
+		BuypackIndex: 3,
+		Practice:     true,
+		Host:         playerName,
+		Hosted:       true,
+		Puzzle: &PuzzleAttempt{
+			ID:      p.ID,
+			Target:  p.Target,
+			Defence: p.Player != p.Declarer,
+		},
+	}
+	room.Sides[3].Cards = []Card{}
+
+	room.Game = &Game{
+		Dealer:   3,
+		Players:  []int{0, 1, 2},
+		Turn:     0,
+		Bids:     []BidInfo{},
+		Declarer: p.Declarer,
+		Contract: p.Contract,
+		Whists:   []WhistInfo{},
+		Hands:    pos.Hands,
+		Buypack:  []Card{},
+		Dropped:  []Card{},
+	}
+
+	for seat, hand := range pos.Hands {
+		side := &room.Sides[seat]
+		side.Cards = hand
+		side.Open = true
+		if seat == p.Player {
+			side.Name = playerName
+		} else {
+			side.Name = BotName(room.ID, seat)
+			side.Bot = "solver"
+		}
+	}
+
+	if !p.Contract.IsMisere() {
+		for seat := range pos.Hands {
+			if seat != p.Declarer {
+				room.Game.Whists = append(room.Game.Whists, WhistInfo{Player: room.Sides[seat].Name, Whist: true})
+			}
+		}
+	}
+
+	return room, nil
+}
+
+func (a *PuzzleAttempt) finish(r *Room) {
+	tricks := r.Sides[r.Game.Declarer].Tricks
+	made := tricks >= a.Target
+	if r.Game.Contract.IsMisere() {
+		made = tricks <= a.Target
+	}
+
+	a.Finished = true
+	a.Solved = made != a.Defence
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPuzzlesSolvable(t *testing.T) {
+	require.NotEmpty(t, Puzzles)
+	for _, p := range Puzzles {
+		solvable, err := p.Solvable()
+		require.NoError(t, err)
+		assert.True(t, solvable, p.ID)
+	}
+
+	_, err := LoadPuzzles([]byte(`[{"id": "x", "hands": ["AS", "AS", "KS"], "contract": "6S"}]`))
+	assert.Error(t, err)
+}
+
+func playPuzzle(t *testing.T, room *Room, firstCard Card) {
+	solver := &SolverStrategy{}
+	for {
+		index, kind := room.Decision()
+		if kind == DecisionNone {
+			return
+		}
+		require.Equal(t, DecisionMove, kind)
+
+		action := solver.Decide(room.ViewFor(index))
+		if room.Sides[index].Bot == "" && len(room.Game.Plays) == 0 {
+			for i, c := range room.Sides[index].Cards {
+				if c == firstCard {
+					action.Index = i
+				}
+			}
+		}
+		require.NoError(t, room.Apply(room.Sides[index].Name, kind, action))
+	}
+}
+
+func TestPlayPuzzle(t *testing.T) {
+	puzzle, err := FindPuzzle("drop-the-honours")
+	require.NoError(t, err)
+
+	room, err := NewPuzzleRoom(puzzle, "evgsol")
+	require.NoError(t, err)
+	playPuzzle(t, room, Card{SuitSpades, "A"})
+	assert.True(t, room.Puzzle.Finished)
+	assert.True(t, room.Puzzle.Solved)
+	assert.Equal(t, 5, room.Sides[0].Tricks)
+	assert.Error(t, room.Shuffle("evgsol"))
+
+	room, err = NewPuzzleRoom(puzzle, "evgsol")
+	require.NoError(t, err)
+	playPuzzle(t, room, Card{SuitSpades, "7"})
+	assert.True(t, room.Puzzle.Finished)
+	assert.False(t, room.Puzzle.Solved)
+}
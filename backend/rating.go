@@ -0,0 +1,144 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRating is the rating of the players who have not played yet.
+	DefaultRating = 1500
+	RatingK       = 20
+	// The first pulkas of a player are provisional: they move the rating
+	// faster and keep the player off the leaderboard.
+	ProvisionalK      = 40
+	ProvisionalPulkas = 10
+	// MaxRatingHistory is the number of the last changes kept per user.
+	MaxRatingHistory = 100
+	MaxLeaderboard   = 100
+)
+
+type RatingChange struct {
+	Time   time.Time `json:"time" bson:"time"`
+	RoomID RoomID    `json:"roomId" bson:"roomId"`
+	Before float64   `json:"before" bson:"before"`
+	After  float64   `json:"after" bson:"after"`
+}
+
+type RatingEntry struct {
+	Player      string         `json:"player"`
+	Rating      float64        `json:"rating"`
+	Pulkas      int            `json:"pulkas"`
+	Provisional bool           `json:"provisional"`
+	History     []RatingChange `json:"history,omitempty"`
+}
+
+func (u *User) RatingEntry() RatingEntry {
+	rating := u.Rating
+	if u.RatedPulkas == 0 {
+		rating = DefaultRating
+	}
+
+	return RatingEntry{
+		Player:      u.Login,
+		Rating:      rating,
+		Pulkas:      u.RatedPulkas,
+		Provisional: u.RatedPulkas < ProvisionalPulkas,
+	}
+}
+
+// Rated tells whether the finished pulka of the room changes the ratings:
+// the teaching tables and the tables with bots do not count.
+func (r *Room) Rated() bool {
+	if r.Practice || r.Puzzle != nil {
+		return false
+	}
+
+	if r.Score == nil || !r.Score.Finished || r.Score.Aborted {
+		return false
+	}
+
+	for _, side := range r.Sides {
+		if side.Bot != "" {
+			return false
+		}
+	}
+	for _, p := range r.Score.Players {
+		if strings.HasPrefix(p.Player, BotNamePrefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PulkaResult is the final balance of the player. The player who forfeited
+// the pulka loses to everybody whatever the balance.
+type PulkaResult struct {
+	Balance float64
+	Forfeit bool
+}
+
+// compare is positive when the result is better than the other one and zero
+// for a tie.
+func (r PulkaResult) compare(other PulkaResult) int {
+	switch {
+	case r.Forfeit != other.Forfeit && r.Forfeit:
+		return -1
+	case r.Forfeit != other.Forfeit:
+		return 1
+	case r.Balance > other.Balance:
+		return 1
+	case r.Balance < other.Balance:
+		return -1
+	}
+
+	return 0
+}
+
+// Results are the final balances of the sheet with the forfeit marked.
+func (s *ScoreSheet) Results() []PulkaResult {
+	balances := s.Balances()
+	res := make([]PulkaResult, len(balances))
+	for i, b := range balances {
+		res[i].Balance = b
+	}
+	if i := s.index(s.Forfeit); i != -1 {
+		res[i].Forfeit = true
+	}
+
+	return res
+}
+
+// RatingDeltas are the changes of a multiplayer Elo: the pulka is played
+// as a match between every pair of the players, won by the better result.
+// The balances sum up to zero, so only their order matters.
+func RatingDeltas(ratings []float64, results []PulkaResult, pulkas []int) []float64 {
+	n := len(ratings)
+	res := make([]float64, n)
+	for i := range ratings {
+		for j := range ratings {
+			if i == j {
+				continue
+			}
+
+			actual := 0.5
+			if c := results[i].compare(results[j]); c > 0 {
+				actual = 1
+			} else if c < 0 {
+				actual = 0
+			}
+			expected := 1 / (1 + math.Pow(10, (ratings[j]-ratings[i])/400))
+			res[i] += actual - expected
+		}
+
+		k := float64(RatingK)
+		if pulkas[i] < ProvisionalPulkas {
+			k = ProvisionalK
+		}
+		res[i] *= k / float64(n-1)
+	}
+
+	return res
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func balanceResults(balances ...float64) []PulkaResult {
+	res := make([]PulkaResult, len(balances))
+	for i, b := range balances {
+		res[i].Balance = b
+	}
+
+	return res
+}
+
+func TestRatingDeltas(t *testing.T) {
+	deltas := RatingDeltas([]float64{1500, 1500, 1500}, balanceResults(30, -10, -20), []int{20, 20, 20})
+	assert.InDelta(t, 10, deltas[0], 1e-9)
+	assert.InDelta(t, 0, deltas[1], 1e-9)
+	assert.InDelta(t, -10, deltas[2], 1e-9)
+
+	deltas = RatingDeltas([]float64{1700, 1500, 1500}, balanceResults(0, 0, 0), []int{20, 20, 0})
+	assert.Less(t, deltas[0], 0.0)
+	assert.Greater(t, deltas[2], deltas[1])
+
+	sum := 0.0
+	for _, d := range RatingDeltas([]float64{1600, 1450, 1520, 1500}, balanceResults(5, -40, 20, 15), []int{20, 20, 20, 20}) {
+		sum += d
+	}
+	assert.InDelta(t, 0, sum, 1e-9)
+}
+
+func TestRatedPulka(t *testing.T) {
+	newRoom := func() *Room {
+		room := &Room{Sides: []RoomSideInfo{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: EMPTY_SIDE}}}
+		room.Score = NewScoreSheet([]string{"a", "b", "c"}, ConventionSochi, 10)
+		room.Score.Finished = true
+		return room
+	}
+
+	assert.True(t, newRoom().Rated())
+
+	room := newRoom()
+	room.Practice = true
+	assert.False(t, room.Rated())
+
+	room = newRoom()
+	room.Sides[1].Bot = ExternalBot
+	assert.False(t, room.Rated())
+
+	room = newRoom()
+	room.Score.Players[2].Player = BotName(room.ID, 2)
+	assert.False(t, room.Rated())
+
+	room = newRoom()
+	room.Score.Aborted = true
+	assert.False(t, room.Rated())
+
+	room = newRoom()
+	room.Score.Forfeit = "b"
+	room.Score.Players[0].Mountain = 10
+	results := room.Score.Results()
+	assert.True(t, results[1].Forfeit)
+	assert.Equal(t, room.Score.Balances()[1], results[1].Balance)
+	assert.Less(t, results[0].Balance, results[2].Balance)
+	assert.Less(t, results[1].compare(results[0]), 0)
+
+	deltas := RatingDeltas([]float64{1500, 1500, 1500}, results, []int{20, 20, 20})
+	assert.InDelta(t, -10, deltas[1], 1e-9)
+}
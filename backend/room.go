@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math/rand"
 	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/globalsign/mgo"
 	"go.mongodb.org/mongo-driver/bson"
@@ -44,6 +46,24 @@ func (d *RoomDAO) FindOneByPlayer(ctx context.Context, playerName string) (*Room
 	return &result, nil
 }
 
+func (d *RoomDAO) FindOneBySpectator(ctx context.Context, spectatorName string) (*Room, error) {
+	var result Room
+	if err := d.collection.Find(bson.M{"spectators": spectatorName}).One(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (d *RoomDAO) FindByInvited(ctx context.Context, name string) ([]Room, error) {
+	var result []Room
+	if err := d.collection.Find(bson.M{"settings.invited": name}).All(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (d *RoomDAO) FindAll(ctx context.Context) ([]Room, error) {
 	var result []Room
 	if err := d.collection.Find(bson.M{}).All(&result); err != nil {
@@ -53,6 +73,26 @@ func (d *RoomDAO) FindAll(ctx context.Context) ([]Room, error) {
 	return result, nil
 }
 
+func (d *RoomDAO) FindWithGame(ctx context.Context) ([]Room, error) {
+	var result []Room
+	if err := d.collection.Find(bson.M{"game": bson.M{"$ne": nil}}).All(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindWithExpiredProposal returns the rooms whose proposal has expired
+// before the time.
+func (d *RoomDAO) FindWithExpiredProposal(ctx context.Context, now time.Time) ([]Room, error) {
+	var result []Room
+	if err := d.collection.Find(bson.M{"proposal.expires": bson.M{"$lt": now}}).All(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (d *RoomDAO) Insert(ctx context.Context, room *Room) (*Room, error) {
 	if room.ID.IsZero() {
 		room.ID = NewRoomID()
@@ -69,6 +109,37 @@ func (d *RoomDAO) Update(ctx context.Context, room *Room) error {
 	return d.collection.UpdateId(room.ID, room)
 }
 
+// MarkRated marks the pulka of the room as rated, only one of the concurrent
+// calls succeeds.
+func (d *RoomDAO) MarkRated(ctx context.Context, roomID RoomID) error {
+	return d.collection.Update(bson.M{
+		"_id":         roomID,
+		"score.rated": bson.M{"$ne": true},
+	}, bson.M{
+		"$set": bson.M{
+			"score.rated": true,
+		},
+	})
+}
+
+// UpdateSince writes the room only if the decision pending since the given
+// time has not been made meanwhile.
+func (d *RoomDAO) UpdateSince(ctx context.Context, room *Room, since time.Time) error {
+	return d.collection.Update(bson.M{
+		"_id":                room.ID,
+		"game.decisionSince": since,
+	}, room)
+}
+
+// UpdateProposal writes the room only if the proposal expiring at the given
+// time has not been closed meanwhile.
+func (d *RoomDAO) UpdateProposal(ctx context.Context, room *Room, expires time.Time) error {
+	return d.collection.Update(bson.M{
+		"_id":              room.ID,
+		"proposal.expires": expires,
+	}, room)
+}
+
 func (d *RoomDAO) ToReady(ctx context.Context, roomID RoomID) error {
 	return d.collection.Update(bson.M{
 		"_id":    roomID,
@@ -217,6 +288,28 @@ func (d *RoomDAO) ChangeVisibility(
 	})
 }
 
+func (d *RoomDAO) AddSpectator(ctx context.Context, roomID RoomID, spectatorName string) error {
+	return d.collection.Update(bson.M{
+		"_id":          roomID,
+		"noSpectators": bson.M{"$ne": true},
+	}, bson.M{
+		"$addToSet": bson.M{
+			"spectators": spectatorName,
+		},
+	})
+}
+
+func (d *RoomDAO) RemoveSpectator(ctx context.Context, spectatorName string) error {
+	_, err := d.collection.UpdateAll(bson.M{
+		"spectators": spectatorName,
+	}, bson.M{
+		"$pull": bson.M{
+			"spectators": spectatorName,
+		},
+	})
+	return err
+}
+
 func (d *RoomDAO) Remove(ctx context.Context, roomID RoomID) error {
 	return d.collection.Remove(bson.M{
 		"_id": roomID,
@@ -229,16 +322,49 @@ func (d *RoomDAO) RemoveAll(ctx context.Context) error {
 }
 
 type RoomManager struct {
-	dao *RoomDAO
+	dao        *RoomDAO
+	deals      *DealDAO
+	chat       *ChatDAO
+	stats      *StatsDAO
+	chatFilter *ChatFilter
+	users      *UserManager
 }
 
 func NewRoomManager(dao *RoomDAO) *RoomManager {
 	return &RoomManager{
-		dao: dao,
+		dao:   dao,
+		deals: NewDealDAO(dao.collection.Database.Session),
+		chat:  NewChatDAO(dao.collection.Database.Session),
+		stats: NewStatsDAO(dao.collection.Database.Session),
+	}
+}
+
+func (m *RoomManager) SetChatFilter(filter *ChatFilter) {
+	m.chatFilter = filter
+}
+
+// SetUsers lets the finished pulkas update the ratings of the players.
+func (m *RoomManager) SetUsers(users *UserManager) {
+	m.users = users
+}
+
+// PlayerRating is the default one until the users are set.
+func (m *RoomManager) PlayerRating(ctx context.Context, playerName string) (float64, error) {
+	if m.users == nil {
+		return DefaultRating, nil
 	}
+
+	entry, err := m.users.Rating(ctx, playerName)
+	if err != nil {
+		return 0, err
+	}
+
+	return entry.Rating, nil
 }
 
-func (m *RoomManager) GetAll(ctx context.Context) ([]RoomView, error) {
+// GetAll lists the rooms visible to the user, the anonymous viewer has an
+// empty name.
+func (m *RoomManager) GetAll(ctx context.Context, viewerName string) ([]RoomView, error) {
 	rooms, err := m.dao.FindAll(ctx)
 	if err != nil {
 		return nil, err
@@ -246,12 +372,19 @@ func (m *RoomManager) GetAll(ctx context.Context) ([]RoomView, error) {
 
 	var result []RoomView
 	for _, room := range rooms {
+		if !room.VisibleTo(viewerName) {
+			continue
+		}
 		result = append(result, room.ToView())
 	}
 
 	return result, nil
 }
 
+func (m *RoomManager) GetOne(ctx context.Context, roomID RoomID) (*Room, error) {
+	return m.dao.FindOneByID(ctx, roomID)
+}
+
 func (m *RoomManager) GetOneForPlayer(ctx context.Context, playerName string) (*Room, error) {
 	room, err := m.dao.FindOneByPlayer(ctx, playerName)
 	if errors.Is(err, mgo.ErrNotFound) {
@@ -269,56 +402,11 @@ func (m *RoomManager) Shuffle(ctx context.Context, roomID RoomID, playerName str
 		return err
 	}
 
-	if room.PlayersCount < 3 || room.PlayersCount > 4 {
-		return errors.New("wrong players count")
-	}
-
-	var allCards []Card
-	for _, s := range AllSuits {
-		for _, r := range AllRanks {
-			allCards = append(allCards, Card{
-				Suit: s,
-				Rank: r,
-			})
-		}
-	}
-
-	rand.Shuffle(len(allCards), func(i, j int) { allCards[i], allCards[j] = allCards[j], allCards[i] })
-
-	playerIndex := room.PlayerSideIndex(playerName)
-	buypackIndex := 0
-	var playersIndexes []int
-	if room.PlayersCount == 3 {
-		for i := 0; i < 4; i++ {
-			index := (playerIndex + i) % 4
-			if room.Sides[index].Name == EmptySidePlayerName {
-				buypackIndex = index
-			} else {
-				playersIndexes = append(playersIndexes, index)
-			}
-		}
-	} else {
-		buypackIndex = playerIndex
-		playersIndexes = []int{(playerIndex + 1) % 4, (playerIndex + 2) % 4, (playerIndex + 3) % 4}
-	}
-
-	room.Status = RoomStatusReady
-	room.Sides[buypackIndex].Cards = allCards[:2]
-	room.Sides[buypackIndex].Tricks = 0
-	room.Sides[buypackIndex].Open = false
-	room.Center = nil
-	room.BuypackIndex = buypackIndex
-	room.LastTrick = []CenterCardInfo{}
-	for i := 0; i < 3; i++ {
-		room.Sides[playersIndexes[i]].Cards = allCards[2+i*10 : 2+(i+1)*10]
-		sort.Slice(room.Sides[playersIndexes[i]].Cards, func(l, r int) bool {
-			return room.Sides[playersIndexes[i]].Cards[l].Less(room.Sides[playersIndexes[i]].Cards[r])
-		})
-		room.Sides[playersIndexes[i]].Tricks = 0
-		room.Sides[playersIndexes[i]].Open = false
+	if err := room.Apply(playerName, DecisionShuffle, Action{}); err != nil {
+		return err
 	}
 
-	return m.dao.Update(ctx, room)
+	return m.saveAction(ctx, room)
 }
 
 func (m *RoomManager) OpenBuypack(ctx context.Context, roomID RoomID) error {
@@ -327,6 +415,10 @@ func (m *RoomManager) OpenBuypack(ctx context.Context, roomID RoomID) error {
 		return err
 	}
 
+	if room.Game != nil {
+		return errors.New("buypack is opened by the auction")
+	}
+
 	if room.Status != RoomStatusReady {
 		return errors.New("wrong room status")
 	}
@@ -340,6 +432,13 @@ func (m *RoomManager) TakeBuypack(ctx context.Context, roomID RoomID, playerName
 		return err
 	}
 
+	if room.Game != nil {
+		if err := room.Apply(playerName, DecisionTakeBuypack, Action{}); err != nil {
+			return err
+		}
+		return m.saveAction(ctx, room)
+	}
+
 	if room.Status != RoomStatusBuypackOpened {
 		return errors.New("wrong room status")
 	}
@@ -369,6 +468,13 @@ func (m *RoomManager) Drop(ctx context.Context, roomID RoomID, playerName string
 		return err
 	}
 
+	if room.Game != nil {
+		if err := room.Apply(playerName, DecisionDrop, Action{Indexes: indexes}); err != nil {
+			return err
+		}
+		return m.saveAction(ctx, room)
+	}
+
 	if room.Status != RoomStatusBuypackTaken {
 		return errors.New("wrong room status")
 	}
@@ -410,6 +516,13 @@ func (m *RoomManager) Move(ctx context.Context, roomID RoomID, playerName string
 		return err
 	}
 
+	if room.Game != nil {
+		if err := room.Apply(playerName, DecisionMove, Action{Index: index}); err != nil {
+			return err
+		}
+		return m.saveAction(ctx, room)
+	}
+
 	if room.Status != RoomStatusPlaying && room.Status != RoomStatusAllPass {
 		return errors.New("wrong room status")
 	}
@@ -456,6 +569,10 @@ func (m *RoomManager) TakeTrick(ctx context.Context, roomID RoomID, playerName s
 		return err
 	}
 
+	if room.Game != nil {
+		return errors.New("tricks are taken automatically")
+	}
+
 	if room.Status != RoomStatusPlaying && room.Status != RoomStatusAllPass {
 		return errors.New("wrong room status")
 	}
@@ -491,6 +608,10 @@ func (m *RoomManager) AllPass(ctx context.Context, roomID RoomID) error {
 		return err
 	}
 
+	if room.Game != nil {
+		return errors.New("all pass is set by the auction")
+	}
+
 	if room.Status != RoomStatusReady {
 		return errors.New("wrong room status")
 	}
@@ -503,6 +624,123 @@ func (m *RoomManager) AllPass(ctx context.Context, roomID RoomID) error {
 	return m.dao.AllPass(ctx, roomID, room.BuypackIndex, newBuypackCards, newCenterCards)
 }
 
+// saveAction stores the room after a game action, the next decision is
+// counted from now.
+func (m *RoomManager) saveAction(ctx context.Context, room *Room) error {
+	if room.Game != nil {
+		room.Game.DecisionSince = time.Now()
+	}
+
+	if room.Game != nil && room.Game.Finished && !room.Game.Archived {
+		deal := NewArchivedDeal(room)
+		if err := m.deals.Insert(ctx, deal); err != nil {
+			return err
+		}
+		for _, bucket := range DealBuckets(deal) {
+			if err := m.stats.Add(ctx, bucket); err != nil {
+				return err
+			}
+		}
+		room.Game.Archived = true
+	}
+
+	if m.users != nil && room.Rated() && !room.Score.Rated {
+		err := m.dao.MarkRated(ctx, room.ID)
+		if err != nil && !errors.Is(err, mgo.ErrNotFound) {
+			return err
+		}
+		room.Score.Rated = true
+		if err == nil {
+			if err := m.users.RecordPulka(ctx, room.ID, room.Score, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) play(ctx context.Context, roomID RoomID, action func(room *Room) error) error {
+	room, err := m.dao.FindOneByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	if err := action(room); err != nil {
+		return err
+	}
+
+	return m.saveAction(ctx, room)
+}
+
+// expire performs the action for the decision pending since the given time.
+// The decision made by the player meanwhile is kept and nothing is done.
+func (m *RoomManager) expire(ctx context.Context, roomID RoomID, since time.Time, action func(room *Room) error) error {
+	room, err := m.dao.FindOneByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	if room.Game == nil || !room.Game.DecisionSince.Equal(since) {
+		return nil
+	}
+
+	if err := action(room); err != nil {
+		return err
+	}
+
+	room.Game.DecisionSince = time.Now()
+	err = m.dao.UpdateSince(ctx, room, since)
+	if errors.Is(err, mgo.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return m.saveAction(ctx, room)
+}
+
+// Forfeit finishes the pulka for the player out of time.
+func (m *RoomManager) Forfeit(ctx context.Context, roomID RoomID, since time.Time, playerName string) error {
+	return m.expire(ctx, roomID, since, func(room *Room) error {
+		return room.Forfeit(playerName)
+	})
+}
+
+// Act performs a decision the way the matching operation does.
+func (m *RoomManager) Act(ctx context.Context, roomID RoomID, playerName string, kind DecisionKind, action Action) error {
+	return m.play(ctx, roomID, func(room *Room) error {
+		return room.Apply(playerName, kind, action)
+	})
+}
+
+// Expire performs the expire action of the decision pending since the given
+// time.
+func (m *RoomManager) Expire(ctx context.Context, roomID RoomID, since time.Time, playerName string, kind DecisionKind, action Action) error {
+	return m.expire(ctx, roomID, since, func(room *Room) error {
+		return room.Apply(playerName, kind, action)
+	})
+}
+
+func (m *RoomManager) Bid(ctx context.Context, roomID RoomID, playerName string, bid Bid) error {
+	return m.play(ctx, roomID, func(room *Room) error {
+		return room.Apply(playerName, DecisionBid, Action{Bid: bid})
+	})
+}
+
+func (m *RoomManager) Declare(ctx context.Context, roomID RoomID, playerName string, contract Bid) error {
+	return m.play(ctx, roomID, func(room *Room) error {
+		return room.Apply(playerName, DecisionDeclare, Action{Bid: contract})
+	})
+}
+
+func (m *RoomManager) Whist(ctx context.Context, roomID RoomID, playerName string, whist bool) error {
+	return m.play(ctx, roomID, func(room *Room) error {
+		return room.Apply(playerName, DecisionWhist, Action{Whist: whist})
+	})
+}
+
 func (m *RoomManager) ChangeVisibility(ctx context.Context, roomID RoomID, playerName string) error {
 	room, err := m.dao.FindOneByID(ctx, roomID)
 	if err != nil {
@@ -523,7 +761,36 @@ func (m *RoomManager) ChangeVisibility(ctx context.Context, roomID RoomID, playe
 	return m.dao.ChangeVisibility(ctx, roomID, playerIndex, !room.Sides[playerIndex].Open)
 }
 
-func (m *RoomManager) PlayerIn(ctx context.Context, roomID RoomID, playerName string) error {
+func (m *RoomManager) AddEntropy(ctx context.Context, playerName, entropy string) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		return errors.New("player is not in room")
+	}
+
+	if room.Duplicate != nil {
+		return errors.New("deals are preset")
+	}
+
+	if room.NextSeed == nil {
+		if room.NextSeed, err = NewDealSeed(); err != nil {
+			return err
+		}
+	}
+
+	if err := room.NextSeed.AddEntropy(playerName, entropy); err != nil {
+		return err
+	}
+
+	return m.dao.Update(ctx, room)
+}
+
+// PlayerIn seats the player at the preferred side or, for AnySeat, at an
+// empty one.
+func (m *RoomManager) PlayerIn(ctx context.Context, roomID RoomID, playerName string, seat int) error {
 	room, err := m.GetOneForPlayer(ctx, playerName)
 	if err != nil {
 		return err
@@ -531,6 +798,9 @@ func (m *RoomManager) PlayerIn(ctx context.Context, roomID RoomID, playerName st
 
 	if room != nil {
 		if room.ID == roomID {
+			if room.Return(playerName, time.Now()) {
+				return m.dao.Update(ctx, room)
+			}
 			return nil
 		}
 		return errors.New("player is already in room")
@@ -545,7 +815,11 @@ func (m *RoomManager) PlayerIn(ctx context.Context, roomID RoomID, playerName st
 		return errors.New("wrong room status")
 	}
 
-	if room.PlayersCount >= 4 {
+	if room.Locked {
+		return errors.New("room is locked")
+	}
+
+	if room.PlayersCount >= room.MaxPlayers() {
 		return errors.New("no empty sides")
 	}
 
@@ -560,34 +834,82 @@ func (m *RoomManager) PlayerIn(ctx context.Context, roomID RoomID, playerName st
 		return errors.New("something went wrong")
 	}
 
+	if seat != AnySeat {
+		if seat < 0 || seat >= len(room.Sides) {
+			return errors.New("wrong seat")
+		}
+		if room.Sides[seat].Name != EMPTY_SIDE {
+			return errors.New("seat is taken")
+		}
+		emptyIndex = seat
+	}
+
 	room.Sides[emptyIndex].Name = playerName
 	room.PlayersCount++
+	room.Spectators = removeName(room.Spectators, playerName)
+	if room.Host == "" && !room.Tournament {
+		room.Host = playerName
+		room.Hosted = true
+		room.logHost(playerName, HostActionHost, "")
+	}
+
+	if err := m.dao.RemoveSpectator(ctx, playerName); err != nil {
+		return err
+	}
 
 	return m.dao.Update(ctx, room)
 }
 
-func (m *RoomManager) RoomReady(ctx context.Context, playerName string) error {
+// Join checks the password or the invite token before seating the player.
+func (m *RoomManager) Join(ctx context.Context, roomID RoomID, playerName string, seat int, password, token string) error {
+	room, err := m.dao.FindOneByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	if room.PlayerSideIndex(playerName) == -1 {
+		if err := room.CheckAccess(playerName, password, token); err != nil {
+			return err
+		}
+	}
+
+	return m.PlayerIn(ctx, roomID, playerName, seat)
+}
+
+// SwapSeats proposes the other player to swap the seats, the swap with a bot
+// is made at once.
+func (m *RoomManager) SwapSeats(ctx context.Context, playerName, otherName string) error {
 	room, err := m.GetOneForPlayer(ctx, playerName)
 	if err != nil {
 		return err
 	}
 
-	if room.Status == RoomStatusReady {
-		return nil
+	if room == nil {
+		return errors.New("player is not in room")
 	}
 
-	if room.Status != RoomStatusCreated {
+	if !room.BetweenDeals() {
 		return errors.New("wrong room status")
 	}
 
-	if room.PlayersCount < 3 || room.PlayersCount > 4 {
-		return errors.New("wrong players count")
+	other := room.PlayerSideIndex(otherName)
+	if other == -1 || otherName == playerName {
+		return errors.New("wrong player name")
 	}
 
-	return m.dao.ToReady(ctx, room.ID)
+	if _, ok := Strategies[room.Sides[other].Bot]; ok {
+		if err := room.SwapSeats(room.PlayerSideIndex(playerName), other); err != nil {
+			return err
+		}
+	} else {
+		room.Swap = &SeatSwap{From: playerName, To: otherName}
+	}
+
+	return m.dao.Update(ctx, room)
 }
 
-func (m *RoomManager) PlayerOut(ctx context.Context, playerName string) error {
+// AnswerSwap accepts or declines the swap proposed to the player.
+func (m *RoomManager) AnswerSwap(ctx context.Context, playerName string, accept bool) error {
 	room, err := m.GetOneForPlayer(ctx, playerName)
 	if err != nil {
 		return err
@@ -597,48 +919,920 @@ func (m *RoomManager) PlayerOut(ctx context.Context, playerName string) error {
 		return errors.New("player is not in room")
 	}
 
-	playerIndex := -1
-	for i, side := range room.Sides {
-		if side.Name == playerName {
-			playerIndex = i
-		}
+	if room.Swap == nil || room.Swap.To != playerName {
+		return errors.New("no swap proposed")
 	}
 
-	room.Sides[playerIndex].Name = EMPTY_SIDE
-	room.PlayersCount--
-	room.Status = RoomStatusCreated
+	from := room.PlayerSideIndex(room.Swap.From)
+	if !accept || from == -1 {
+		room.Swap = nil
+		return m.dao.Update(ctx, room)
+	}
 
-	if room.PlayersCount == 0 {
-		return m.dao.Remove(ctx, room.ID)
+	if err := room.SwapSeats(from, room.PlayerSideIndex(playerName)); err != nil {
+		return err
 	}
 
 	return m.dao.Update(ctx, room)
 }
 
-func (m *RoomManager) CreateRoom(ctx context.Context, playerName string) error {
+// Invite returns a new invite token of the player's room and adds the
+// invited user, if any, to the private guests. The invited user may join
+// without the password and sees the room among the invitations.
+func (m *RoomManager) Invite(ctx context.Context, playerName, invitedName string) (RoomID, string, error) {
+	room, err := m.getHostRoom(ctx, playerName)
+	if err != nil {
+		return ZeroRoomID(), "", err
+	}
+
+	if invitedName != "" {
+		if room.Locked || room.Puzzle != nil {
+			return ZeroRoomID(), "", errors.New("room is closed for the new players")
+		}
+		if room.PlayerSideIndex(invitedName) != -1 {
+			return ZeroRoomID(), "", errors.New("wrong player name")
+		}
+	}
+
+	token, err := NewInviteToken()
+	if err != nil {
+		return ZeroRoomID(), "", err
+	}
+
+	if room.Settings == nil {
+		room.Settings, err = NewRoomSettings(false, "", 0)
+		if err != nil {
+			return ZeroRoomID(), "", err
+		}
+	}
+	room.Settings.InviteTokens = append(room.Settings.InviteTokens, token)
+	if invitedName != "" && !room.IsInvited(invitedName) {
+		room.Settings.Invited = append(room.Settings.Invited, invitedName)
+	}
+	room.logHost(playerName, HostActionInvite, invitedName)
+
+	return room.ID, token, m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) GetAllWithGame(ctx context.Context) ([]Room, error) {
+	return m.dao.FindWithGame(ctx)
+}
+
+// SetExternalBot marks the player's side as played by an external bot, so
+// its decisions are timed.
+func (m *RoomManager) SetExternalBot(ctx context.Context, playerName string) error {
 	room, err := m.GetOneForPlayer(ctx, playerName)
 	if err != nil {
 		return err
 	}
 
-	if room != nil {
-		return nil
+	if room == nil {
+		return errors.New("player is not in room")
 	}
 
-	newRoom := &Room{
-		Sides: []RoomSideInfo{{
-			Name: playerName,
-		}, {
-			Name: EMPTY_SIDE,
-		}, {
-			Name: EMPTY_SIDE,
-		}, {
-			Name: EMPTY_SIDE,
+	room.Sides[room.PlayerSideIndex(playerName)].Bot = ExternalBot
+	return m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) AddBot(ctx context.Context, playerName, strategy string) error {
+	if _, ok := Strategies[strategy]; !ok {
+		return errors.New("unknown strategy")
+	}
+
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		return errors.New("player is not in room")
+	}
+
+	if room.Status != RoomStatusCreated {
+		return errors.New("wrong room status")
+	}
+
+	if room.PlayersCount >= room.MaxPlayers() {
+		return errors.New("no empty sides")
+	}
+
+	emptyIndex := -1
+	for i, side := range room.Sides {
+		if side.Name == EMPTY_SIDE {
+			emptyIndex = i
+			break
+		}
+	}
+
+	if emptyIndex == -1 {
+		return errors.New("no empty sides")
+	}
+
+	room.Sides[emptyIndex].Name = BotName(room.ID, emptyIndex)
+	room.Sides[emptyIndex].Bot = strategy
+	room.PlayersCount++
+
+	return m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) RemoveBot(ctx context.Context, playerName string, sideIndex int) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		return errors.New("player is not in room")
+	}
+
+	if room.Status != RoomStatusCreated {
+		return errors.New("wrong room status")
+	}
+
+	if sideIndex < 0 || sideIndex >= len(room.Sides) || room.Sides[sideIndex].Bot == "" {
+		return errors.New("wrong side index")
+	}
+
+	room.Sides[sideIndex].Name = EMPTY_SIDE
+	room.Sides[sideIndex].Bot = ""
+	room.PlayersCount--
+
+	return m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) RoomReady(ctx context.Context, playerName string) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room.Status == RoomStatusReady {
+		return nil
+	}
+
+	if room.Status != RoomStatusCreated {
+		return errors.New("wrong room status")
+	}
+
+	if room.PlayersCount < 3 || room.PlayersCount > 4 {
+		return errors.New("wrong players count")
+	}
+
+	return m.dao.ToReady(ctx, room.ID)
+}
+
+func (m *RoomManager) PlayerOut(ctx context.Context, playerName string) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		return errors.New("player is not in room")
+	}
+
+	playerIndex := -1
+	for i, side := range room.Sides {
+		if side.Name == playerName {
+			playerIndex = i
+		}
+	}
+
+	if room.Puzzle != nil {
+		return m.dao.Remove(ctx, room.ID)
+	}
+
+	if room.Away != nil && room.Away.Player == playerName {
+		return nil
+	}
+
+	if room.CanReserve(playerName) {
+		room.Reserve(playerName, time.Now())
+		return m.dao.Update(ctx, room)
+	}
+
+	room.leave(playerIndex)
+
+	if !room.hasHumans() {
+		return m.dao.Remove(ctx, room.ID)
+	}
+
+	return m.dao.Update(ctx, room)
+}
+
+// VoteAbsence is the vote of a player on how to go on without the absent
+// one after the grace period.
+func (m *RoomManager) VoteAbsence(ctx context.Context, playerName string, vote AbsenceVote) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		return errors.New("player is not in room")
+	}
+
+	if vote.Option == AbsenceSubstitute {
+		other, err := m.GetOneForPlayer(ctx, vote.Substitute)
+		if err != nil {
+			return err
+		}
+		if other != nil {
+			return errors.New("substitute is in another room")
+		}
+	}
+
+	vote.Player = playerName
+	if _, err := room.VoteAbsence(vote, time.Now()); err != nil {
+		return err
+	}
+
+	return m.dao.Update(ctx, room)
+}
+
+// CreateRoom seats the player at a new room, nil settings make it public for
+// four players.
+func (m *RoomManager) CreateRoom(ctx context.Context, playerName string, settings *RoomSettings) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room != nil {
+		return nil
+	}
+
+	seed, err := NewDealSeed()
+	if err != nil {
+		return err
+	}
+
+	newRoom := &Room{
+		Sides: []RoomSideInfo{{
+			Name: playerName,
+		}, {
+			Name: EMPTY_SIDE,
+		}, {
+			Name: EMPTY_SIDE,
+		}, {
+			Name: EMPTY_SIDE,
 		}},
 		Status:       RoomStatusCreated,
 		PlayersCount: 1,
 		BuypackIndex: 0,
+		NextSeed:     seed,
+		Host:         playerName,
+		Hosted:       true,
+		Settings:     settings,
 	}
 	_, err = m.dao.Insert(ctx, newRoom)
 	return err
 }
+
+// CreateReadyRoom seats the players at a new room in the given order and
+// makes it ready. The room is removed if not all of them can be seated.
+func (m *RoomManager) CreateReadyRoom(ctx context.Context, playerNames []string, settings *RoomSettings) (RoomID, error) {
+	if err := m.CreateRoom(ctx, playerNames[0], settings); err != nil {
+		return RoomID{}, err
+	}
+
+	room, err := m.GetOneForPlayer(ctx, playerNames[0])
+	if err != nil {
+		return RoomID{}, err
+	}
+
+	if err := m.seatReady(ctx, room.ID, playerNames); err != nil {
+		if removeErr := m.dao.Remove(ctx, room.ID); removeErr != nil {
+			return RoomID{}, fmt.Errorf("%w, the room is not removed: %v", err, removeErr)
+		}
+		return RoomID{}, err
+	}
+
+	return room.ID, nil
+}
+
+func (m *RoomManager) seatReady(ctx context.Context, roomID RoomID, playerNames []string) error {
+	for _, name := range playerNames[1:] {
+		if err := m.PlayerIn(ctx, roomID, name, AnySeat); err != nil {
+			return err
+		}
+	}
+
+	return m.RoomReady(ctx, playerNames[0])
+}
+
+// SetTournamentTable makes the room a hostless table of the tournament, so
+// its settings stay as the tournament fixed them. The duplicate table is
+// nil for the Swiss tournaments.
+func (m *RoomManager) SetTournamentTable(ctx context.Context, roomID RoomID, duplicate *DuplicateTable) error {
+	room, err := m.dao.FindOneByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	room.Tournament = true
+	room.Host = ""
+	room.Duplicate = duplicate
+	return m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) GetDeals(ctx context.Context, playerName string) ([]ArchivedDeal, error) {
+	return m.deals.FindByPlayer(ctx, playerName, MaxListedDeals)
+}
+
+// Stats sums up the precomputed stats of the player.
+func (m *RoomManager) Stats(ctx context.Context, playerName string, filter StatsFilter) (StatsReport, error) {
+	buckets, err := m.stats.Find(ctx, playerName, filter)
+	if err != nil {
+		return StatsReport{}, err
+	}
+
+	stats := NewPlayerStats()
+	for _, b := range buckets {
+		stats.Add(b.Stats)
+	}
+
+	return NewStatsReport(stats), nil
+}
+
+// BackfillStats counts the stats of the archived deals anew.
+func (m *RoomManager) BackfillStats(ctx context.Context) error {
+	if err := m.stats.RemoveAll(ctx); err != nil {
+		return err
+	}
+
+	return m.deals.ForEach(ctx, func(deal *ArchivedDeal) error {
+		// The puzzles archived before they were marked have no bids.
+		if len(deal.Bids) == 0 {
+			return nil
+		}
+		for _, bucket := range DealBuckets(deal) {
+			if err := m.stats.Add(ctx, bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Analyse solves the archived deal for one of its players.
+func (m *RoomManager) Analyse(ctx context.Context, dealID DealID, playerName string) (*DealAnalysis, error) {
+	deal, err := m.deals.FindOneByID(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+
+	if deal.seat(playerName) == -1 {
+		return nil, errors.New("player did not play the deal")
+	}
+
+	return deal.Analyse()
+}
+
+func (m *RoomManager) Mistakes(ctx context.Context, dealID DealID, playerName string) (*MistakesReport, error) {
+	deal, err := m.deals.FindOneByID(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+
+	if deal.seat(playerName) == -1 {
+		return nil, errors.New("player did not play the deal")
+	}
+
+	if deal.Review != nil {
+		deal.Review.Deal = deal
+		return deal.Review, nil
+	}
+
+	report, err := deal.Mistakes()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.deals.SetReview(ctx, dealID, report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// SetPractice marks the room as a practice one, it is only allowed before
+// the first deal.
+func (m *RoomManager) SetPractice(ctx context.Context, playerName string, practice bool) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		return errors.New("player is not in room")
+	}
+
+	if room.Game != nil {
+		return errors.New("game is already started")
+	}
+
+	room.Practice = practice
+	if !practice {
+		room.Hints = nil
+	}
+	return m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) SetHints(ctx context.Context, playerName string, enabled bool) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		return errors.New("player is not in room")
+	}
+
+	if !room.Practice {
+		return errors.New("hints are only allowed in practice rooms")
+	}
+
+	room.Hints = removeName(room.Hints, playerName)
+	if enabled {
+		room.Hints = append(room.Hints, playerName)
+	}
+	return m.dao.Update(ctx, room)
+}
+
+// Propose opens a proposal of the player for the others to vote.
+func (m *RoomManager) Propose(ctx context.Context, playerName, kind string) (RoomID, error) {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return ZeroRoomID(), err
+	}
+
+	if room == nil {
+		return ZeroRoomID(), errors.New("player is not in room")
+	}
+
+	if err := room.Propose(playerName, kind, time.Now()); err != nil {
+		return ZeroRoomID(), err
+	}
+
+	return room.ID, m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) VoteProposal(ctx context.Context, playerName string, approve bool) (RoomID, error) {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return ZeroRoomID(), err
+	}
+
+	if room == nil {
+		return ZeroRoomID(), errors.New("player is not in room")
+	}
+
+	if err := room.VoteProposal(playerName, approve, time.Now()); err != nil {
+		return ZeroRoomID(), err
+	}
+
+	return room.ID, m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) GetAllWithExpiredProposal(ctx context.Context, now time.Time) ([]Room, error) {
+	return m.dao.FindWithExpiredProposal(ctx, now)
+}
+
+// ExpireProposal closes the expired proposal of the room unless it has been
+// closed meanwhile.
+func (m *RoomManager) ExpireProposal(ctx context.Context, room *Room, now time.Time) error {
+	if room.Proposal == nil {
+		return nil
+	}
+
+	expires := room.Proposal.Expires
+	room.ExpireProposal(now)
+	err := m.dao.UpdateProposal(ctx, room, expires)
+	if errors.Is(err, mgo.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// SendMessage writes the message of the user to the user's channel of the
+// room.
+func (m *RoomManager) SendMessage(ctx context.Context, roomID RoomID, author, text string) error {
+	room, err := m.dao.FindOneByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	channel, _, err := room.ChatChannels(author)
+	if err != nil {
+		return err
+	}
+
+	if containsName(room.Muted, author) {
+		return errors.New("user is muted")
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > MaxMessageLength {
+		return errors.New("wrong message length")
+	}
+
+	return m.chat.Insert(ctx, &ChatMessage{
+		ID:      NewRoomID(),
+		RoomID:  roomID,
+		Channel: channel,
+		Author:  author,
+		Text:    m.chatFilter.Apply(text),
+		Time:    time.Now(),
+	})
+}
+
+// GetMessages returns a page of the messages the user reads in the room
+// written before the given one.
+func (m *RoomManager) GetMessages(ctx context.Context, roomID RoomID, name string, before MessageID, limit int) ([]ChatMessage, error) {
+	room, err := m.dao.FindOneByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, channels, err := room.ChatChannels(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > MaxChatPage {
+		limit = MaxChatPage
+	}
+
+	return m.chat.FindByRoom(ctx, roomID, channels, before, limit)
+}
+
+// Mute forbids the user to write to the chat of the host's room.
+func (m *RoomManager) Mute(ctx context.Context, playerName, mutedName string, muted bool) error {
+	room, err := m.getHostRoom(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	room.Muted = removeName(room.Muted, mutedName)
+	if muted {
+		room.Muted = append(room.Muted, mutedName)
+		room.logHost(playerName, HostActionMute, mutedName)
+	} else {
+		room.logHost(playerName, HostActionUnmute, mutedName)
+	}
+	return m.dao.Update(ctx, room)
+}
+
+// Lobby returns the status of the online users: idle, seated in a room or
+// playing a pulka.
+func (m *RoomManager) Lobby(ctx context.Context, names []string) ([]OnlinePlayer, error) {
+	rooms, err := m.dao.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seated := map[string]*Room{}
+	for i := range rooms {
+		for _, side := range rooms[i].Sides {
+			if side.Name != EMPTY_SIDE {
+				seated[side.Name] = &rooms[i]
+			}
+		}
+	}
+
+	res := []OnlinePlayer{}
+	for _, name := range names {
+		player := OnlinePlayer{Name: name, Status: PresenceIdle}
+		if room, ok := seated[name]; ok {
+			player.Status = PresenceInRoom
+			if room.Game != nil && room.PulkaStarted() {
+				player.Status = PresencePlaying
+			}
+			if !room.IsPrivate() && room.Puzzle == nil {
+				player.RoomID = room.ID.String()
+			}
+		}
+		res = append(res, player)
+	}
+
+	return res, nil
+}
+
+// Invitations lists the rooms the user is invited to and may still join.
+func (m *RoomManager) Invitations(ctx context.Context, name string) ([]Invitation, error) {
+	rooms, err := m.dao.FindByInvited(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []Invitation{}
+	for _, room := range rooms {
+		if room.Status != RoomStatusCreated || room.PlayerSideIndex(name) != -1 {
+			continue
+		}
+		res = append(res, Invitation{
+			RoomID:  room.ID.String(),
+			Host:    room.Host,
+			Players: room.ToView().Players,
+		})
+	}
+
+	return res, nil
+}
+
+func (m *RoomManager) DeclineInvitation(ctx context.Context, roomID RoomID, name string) error {
+	room, err := m.dao.FindOneByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	if !room.IsInvited(name) {
+		return errors.New("no invitation")
+	}
+
+	room.Settings.Invited = removeName(room.Settings.Invited, name)
+	return m.dao.Update(ctx, room)
+}
+
+// SetAutoPlay switches the auto-play of the forced moves for the player.
+func (m *RoomManager) SetAutoPlay(ctx context.Context, playerName string, enabled bool) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		return errors.New("player is not in room")
+	}
+
+	room.AutoPlay = removeName(room.AutoPlay, playerName)
+	if enabled {
+		room.AutoPlay = append(room.AutoPlay, playerName)
+	}
+	return m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) QueuePreMove(ctx context.Context, playerName string, card *Card) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		return errors.New("player is not in room")
+	}
+
+	if err := room.QueuePreMove(playerName, card); err != nil {
+		return err
+	}
+
+	return m.dao.Update(ctx, room)
+}
+
+// PlayQueued makes the queued or forced move of the player to move now, it
+// tells whether the move was made.
+func (m *RoomManager) PlayQueued(ctx context.Context, roomID RoomID) (bool, error) {
+	room, err := m.dao.FindOneByID(ctx, roomID)
+	if err != nil {
+		return false, err
+	}
+
+	queued := len(room.PreMoves)
+	index, action, ok := room.QueuedMove()
+	if !ok {
+		if len(room.PreMoves) != queued {
+			return false, m.dao.Update(ctx, room)
+		}
+		return false, nil
+	}
+
+	return true, m.Act(ctx, roomID, room.Sides[index].Name, DecisionMove, action)
+}
+
+func removeName(names []string, name string) []string {
+	var res []string
+	for _, n := range names {
+		if n != name {
+			res = append(res, n)
+		}
+	}
+
+	return res
+}
+
+// StartPuzzle creates a single player room with the puzzle.
+func (m *RoomManager) StartPuzzle(ctx context.Context, playerName string, puzzle *Puzzle) (*Room, error) {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if room != nil {
+		return nil, errors.New("player is already in room")
+	}
+
+	room, err = NewPuzzleRoom(puzzle, playerName)
+	if err != nil {
+		return nil, err
+	}
+	room.Game.DecisionSince = time.Now()
+
+	return m.dao.Insert(ctx, room)
+}
+
+func (m *RoomManager) GetOneForSpectator(ctx context.Context, spectatorName string) (*Room, error) {
+	room, err := m.dao.FindOneBySpectator(ctx, spectatorName)
+	if errors.Is(err, mgo.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// Watch makes the user a spectator of the room, a user watches one room at
+// a time.
+func (m *RoomManager) Watch(ctx context.Context, roomID RoomID, spectatorName string) error {
+	room, err := m.dao.FindOneByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	if room.PlayerSideIndex(spectatorName) != -1 {
+		return errors.New("player is in the room")
+	}
+
+	if room.Puzzle != nil || room.NoSpectators {
+		return errors.New("spectators are not allowed")
+	}
+
+	if room.IsPrivate() && !room.IsInvited(spectatorName) {
+		return errors.New("room is private")
+	}
+
+	if err := m.dao.RemoveSpectator(ctx, spectatorName); err != nil {
+		return err
+	}
+
+	return m.dao.AddSpectator(ctx, roomID, spectatorName)
+}
+
+func (m *RoomManager) Unwatch(ctx context.Context, spectatorName string) error {
+	return m.dao.RemoveSpectator(ctx, spectatorName)
+}
+
+// SetSpectating lets the host allow or forbid spectators, forbidding sends
+// the current ones away.
+func (m *RoomManager) SetSpectating(ctx context.Context, playerName string, enabled bool) error {
+	room, err := m.getHostRoom(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	room.NoSpectators = !enabled
+	if enabled {
+		room.logHost(playerName, HostActionSpectators, "")
+	} else {
+		room.Spectators = nil
+		room.logHost(playerName, HostActionNoSpectators, "")
+	}
+	return m.dao.Update(ctx, room)
+}
+
+// SetKibitzers lets the host choose who sees all the hands and how late.
+func (m *RoomManager) SetKibitzers(ctx context.Context, playerName string, kibitzers []string, delay KibitzDelay) error {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		return errors.New("player is not in room")
+	}
+
+	if !room.IsHost(playerName) {
+		return errors.New("only the host can do it")
+	}
+
+	if !delay.Valid() {
+		return errors.New("wrong delay")
+	}
+
+	for _, name := range kibitzers {
+		if room.PlayerSideIndex(name) != -1 {
+			return errors.New("players cannot be kibitzers")
+		}
+	}
+
+	room.Kibitzers = kibitzers
+	room.KibitzDelay = &delay
+	room.logHost(playerName, HostActionKibitzers, "")
+	return m.dao.Update(ctx, room)
+}
+
+func (m *RoomManager) getHostRoom(ctx context.Context, playerName string) (*Room, error) {
+	room, err := m.GetOneForPlayer(ctx, playerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if room == nil {
+		return nil, errors.New("player is not in room")
+	}
+
+	if !room.IsHost(playerName) {
+		return nil, errors.New("only the host can do it")
+	}
+
+	return room, nil
+}
+
+// Kick removes a player or a bot from the room before the pulka starts.
+func (m *RoomManager) Kick(ctx context.Context, playerName, kickedName string) error {
+	room, err := m.getHostRoom(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	index := room.PlayerSideIndex(kickedName)
+	if index == -1 || kickedName == playerName || kickedName == EMPTY_SIDE {
+		return errors.New("wrong player name")
+	}
+
+	if room.PulkaStarted() {
+		return errors.New("pulka has started")
+	}
+
+	room.removePlayer(index)
+	room.logHost(playerName, HostActionKick, kickedName)
+	return m.dao.Update(ctx, room)
+}
+
+// Lock closes the room for the new players.
+func (m *RoomManager) Lock(ctx context.Context, playerName string, locked bool) error {
+	room, err := m.getHostRoom(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	room.Locked = locked
+	if locked {
+		room.logHost(playerName, HostActionLock, "")
+	} else {
+		room.logHost(playerName, HostActionUnlock, "")
+	}
+	return m.dao.Update(ctx, room)
+}
+
+// UpdateSettings replaces the room settings keeping the invitations, the
+// max players cannot go below the seated ones.
+func (m *RoomManager) UpdateSettings(ctx context.Context, playerName string, settings *RoomSettings) error {
+	room, err := m.getHostRoom(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	if settings.MaxPlayers < room.PlayersCount {
+		return errors.New("too many players")
+	}
+
+	current := room.Settings
+	if current == nil {
+		current = &RoomSettings{MaxPlayers: room.MaxPlayers()}
+	}
+
+	if room.PulkaStarted() && (settings.MaxPlayers != current.MaxPlayers ||
+		settings.GraceSeconds != current.GraceSeconds ||
+		!settings.TimeControl.Equal(current.TimeControl)) {
+		return errors.New("pulka has started")
+	}
+
+	room.Settings = current.update(settings)
+	room.logHost(playerName, HostActionSettings, "")
+	return m.dao.Update(ctx, room)
+}
+
+// CloseRoom removes the room with all its players.
+func (m *RoomManager) CloseRoom(ctx context.Context, playerName string) error {
+	room, err := m.getHostRoom(ctx, playerName)
+	if err != nil {
+		return err
+	}
+
+	return m.dao.Remove(ctx, room.ID)
+}
+
+func (m *RoomManager) Kibitz(ctx context.Context, roomID RoomID, name string) (*KibitzView, error) {
+	room, err := m.dao.FindOneByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !room.IsKibitzer(name) || room.PlayerSideIndex(name) != -1 {
+		return nil, errors.New("kibitzing is not allowed")
+	}
+
+	return room.KibitzView(time.Now())
+}
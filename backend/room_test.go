@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/globalsign/mgo"
 	"github.com/stretchr/testify/assert"
@@ -37,6 +38,9 @@ func TestRoom(t *testing.T) {
 
 func (s *RoomSuite) TearDownTest() {
 	s.DAO.RemoveAll(s.Ctx)
+	s.Manager.chat.RemoveAll(s.Ctx)
+	s.Manager.stats.RemoveAll(s.Ctx)
+	s.Manager.deals.RemoveAll(s.Ctx)
 }
 
 func (s *RoomSuite) TestRoomDAOFindByPlayer() {
@@ -416,7 +420,7 @@ func (s *RoomSuite) TestPlayerIn() {
 		})
 		require.NoError(s.T(), err)
 
-		require.NoError(s.T(), s.Manager.PlayerIn(s.Ctx, room.ID, "kek"))
+		require.NoError(s.T(), s.Manager.PlayerIn(s.Ctx, room.ID, "kek", AnySeat))
 
 		updatedRoom, err := s.DAO.FindOneByID(s.Ctx, room.ID)
 		require.NoError(s.T(), err)
@@ -439,7 +443,7 @@ func (s *RoomSuite) TestPlayerIn() {
 		})
 		require.NoError(s.T(), err)
 
-		err = s.Manager.PlayerIn(s.Ctx, room.ID, "evgsol")
+		err = s.Manager.PlayerIn(s.Ctx, room.ID, "evgsol", AnySeat)
 		require.Error(s.T(), err)
 		require.Equal(s.T(), "player is already in room", err.Error())
 	})
@@ -450,7 +454,7 @@ func (s *RoomSuite) TestPlayerIn() {
 		})
 		require.NoError(s.T(), err)
 
-		err = s.Manager.PlayerIn(s.Ctx, room.ID, "donald trump")
+		err = s.Manager.PlayerIn(s.Ctx, room.ID, "donald trump", AnySeat)
 		require.Error(s.T(), err)
 		require.Equal(s.T(), "wrong room status", err.Error())
 	})
@@ -471,7 +475,7 @@ func (s *RoomSuite) TestPlayerIn() {
 		})
 		require.NoError(s.T(), err)
 
-		err = s.Manager.PlayerIn(s.Ctx, room.ID, "joe biden")
+		err = s.Manager.PlayerIn(s.Ctx, room.ID, "joe biden", AnySeat)
 		require.Error(s.T(), err)
 		require.Equal(s.T(), "no empty sides", err.Error())
 	})
@@ -587,6 +591,28 @@ func (s *RoomSuite) TestPlayerOut() {
 		require.IsType(s.T(), mgo.ErrNotFound, err)
 	})
 
+	s.Run("Only bots left", func() {
+		room, err := s.DAO.Insert(s.Ctx, &Room{
+			Sides: []RoomSideInfo{{
+				Name: "brad pitt",
+			}, {
+				Name: "bot-1",
+				Bot:  "heuristic",
+			}, {
+				Name: "bot-2",
+				Bot:  "random",
+			}},
+			PlayersCount: 3,
+			Status:       RoomStatusCreated,
+		})
+		require.NoError(s.T(), err)
+
+		require.NoError(s.T(), s.Manager.PlayerOut(s.Ctx, "brad pitt"))
+
+		_, err = s.DAO.FindOneByID(s.Ctx, room.ID)
+		require.ErrorIs(s.T(), err, mgo.ErrNotFound)
+	})
+
 	s.Run("Player is not in room", func() {
 		err := s.Manager.PlayerOut(s.Ctx, "elon musk")
 		require.Error(s.T(), err)
@@ -595,7 +621,7 @@ func (s *RoomSuite) TestPlayerOut() {
 }
 
 func (s *RoomSuite) TestCreateRoom() {
-	s.Require().NoError(s.Manager.CreateRoom(s.Ctx, "evgsol"))
+	s.Require().NoError(s.Manager.CreateRoom(s.Ctx, "evgsol", nil))
 
 	room, err := s.Manager.GetOneForPlayer(s.Ctx, "evgsol")
 	s.Require().NoError(err)
@@ -608,7 +634,7 @@ func (s *RoomSuite) TestGetAll() {
 	room2, err := s.DAO.Insert(s.Ctx, &Room{})
 	s.Require().NoError(err)
 
-	rooms, err := s.Manager.GetAll(s.Ctx)
+	rooms, err := s.Manager.GetAll(s.Ctx, "")
 	s.Require().NoError(err)
 
 	if s.Len(rooms, 2) {
@@ -616,3 +642,537 @@ func (s *RoomSuite) TestGetAll() {
 		s.Equal(room2.ID.String(), rooms[1].ID)
 	}
 }
+
+func (s *RoomSuite) TestSpectators() {
+	room, err := s.DAO.Insert(s.Ctx, &Room{
+		Sides: []RoomSideInfo{
+			{Name: "evgsol", Cards: []Card{{SuitSpades, "A"}}},
+			{Name: "solarka", Cards: []Card{{SuitSpades, "K"}}, Open: true},
+			{Name: EMPTY_SIDE, Cards: []Card{{SuitSpades, "Q"}}},
+			{Name: EMPTY_SIDE},
+		},
+		PlayersCount: 2,
+		Host:         "solarka",
+		Hosted:       true,
+	})
+	s.Require().NoError(err)
+
+	s.Error(s.Manager.Watch(s.Ctx, room.ID, "evgsol"))
+	s.Require().NoError(s.Manager.Watch(s.Ctx, room.ID, "psmirnov"))
+
+	watched, err := s.Manager.GetOneForSpectator(s.Ctx, "psmirnov")
+	s.Require().NoError(err)
+	s.Require().NotNil(watched)
+	watched.HideCards(EMPTY_SIDE)
+	s.Equal([]Card{UnknownCard}, watched.Sides[0].Cards)
+	s.Equal([]Card{{SuitSpades, "K"}}, watched.Sides[1].Cards)
+	s.Equal([]Card{UnknownCard}, watched.Sides[2].Cards)
+
+	rooms, err := s.Manager.GetAll(s.Ctx, "")
+	s.Require().NoError(err)
+	s.Equal(1, rooms[0].Spectators)
+
+	s.Error(s.Manager.SetSpectating(s.Ctx, "evgsol", false))
+	s.Require().NoError(s.Manager.SetSpectating(s.Ctx, "solarka", false))
+	watched, err = s.Manager.GetOneForSpectator(s.Ctx, "psmirnov")
+	s.Require().NoError(err)
+	s.Nil(watched)
+	room, err = s.DAO.FindOneByID(s.Ctx, room.ID)
+	s.Require().NoError(err)
+	s.Equal(HostActionNoSpectators, room.HostLog[len(room.HostLog)-1].Action)
+	s.Error(s.Manager.Watch(s.Ctx, room.ID, "psmirnov"))
+}
+
+func (s *RoomSuite) TestRoomSettings() {
+	settings, err := NewRoomSettings(true, "secret", 3)
+	s.Require().NoError(err)
+	s.Require().NoError(s.Manager.CreateRoom(s.Ctx, "evgsol", settings))
+	room, err := s.Manager.GetOneForPlayer(s.Ctx, "evgsol")
+	s.Require().NoError(err)
+
+	rooms, err := s.Manager.GetAll(s.Ctx, "")
+	s.Require().NoError(err)
+	s.Empty(rooms)
+	rooms, err = s.Manager.GetAll(s.Ctx, "evgsol")
+	s.Require().NoError(err)
+	s.Len(rooms, 1)
+
+	s.Error(s.Manager.Join(s.Ctx, room.ID, "solarka", AnySeat, "secret", ""))
+	s.Error(s.Manager.Watch(s.Ctx, room.ID, "solarka"))
+
+	_, token, err := s.Manager.Invite(s.Ctx, "evgsol", "psmirnov")
+	s.Require().NoError(err)
+	rooms, err = s.Manager.GetAll(s.Ctx, "psmirnov")
+	s.Require().NoError(err)
+	s.Len(rooms, 1)
+
+	s.Error(s.Manager.Join(s.Ctx, room.ID, "solarka", AnySeat, "", "wrong"))
+	s.Require().NoError(s.Manager.Join(s.Ctx, room.ID, "solarka", AnySeat, "", token))
+	s.Require().NoError(s.Manager.Join(s.Ctx, room.ID, "psmirnov", AnySeat, "", ""))
+
+	err = s.Manager.AddBot(s.Ctx, "evgsol", "heuristic")
+	s.Require().Error(err)
+	s.Equal("no empty sides", err.Error())
+}
+
+func (s *RoomSuite) TestSeats() {
+	room, err := s.DAO.Insert(s.Ctx, &Room{
+		Sides: []RoomSideInfo{
+			{Name: "evgsol"},
+			{Name: EMPTY_SIDE},
+			{Name: EMPTY_SIDE},
+			{Name: EMPTY_SIDE},
+		},
+		Status:       RoomStatusCreated,
+		PlayersCount: 1,
+	})
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.Manager.PlayerIn(s.Ctx, room.ID, "solarka", 1))
+	err = s.Manager.PlayerIn(s.Ctx, room.ID, "psmirnov", 1)
+	s.Require().Error(err)
+	s.Equal("seat is taken", err.Error())
+	s.Require().NoError(s.Manager.PlayerIn(s.Ctx, room.ID, "psmirnov", 2))
+
+	s.Error(s.Manager.AnswerSwap(s.Ctx, "solarka", true))
+	s.Require().NoError(s.Manager.SwapSeats(s.Ctx, "evgsol", "solarka"))
+	s.Error(s.Manager.AnswerSwap(s.Ctx, "psmirnov", true))
+	s.Require().NoError(s.Manager.AnswerSwap(s.Ctx, "solarka", true))
+
+	updated, err := s.DAO.FindOneByID(s.Ctx, room.ID)
+	s.Require().NoError(err)
+	s.Equal("solarka", updated.Sides[0].Name)
+	s.Equal("evgsol", updated.Sides[1].Name)
+	s.Equal("psmirnov", updated.Sides[2].Name)
+	s.Nil(updated.Swap)
+	s.Equal([]int{3, 0, 1, 2}, updated.RelativeSeats("evgsol"))
+}
+
+func (s *RoomSuite) TestHost() {
+	s.Require().NoError(s.Manager.CreateRoom(s.Ctx, "evgsol", nil))
+	room, err := s.Manager.GetOneForPlayer(s.Ctx, "evgsol")
+	s.Require().NoError(err)
+	s.Require().NoError(s.Manager.PlayerIn(s.Ctx, room.ID, "solarka", AnySeat))
+	s.Require().NoError(s.Manager.PlayerIn(s.Ctx, room.ID, "psmirnov", AnySeat))
+
+	s.Error(s.Manager.Kick(s.Ctx, "solarka", "psmirnov"))
+	s.Require().NoError(s.Manager.Kick(s.Ctx, "evgsol", "psmirnov"))
+	s.Require().NoError(s.Manager.Lock(s.Ctx, "evgsol", true))
+	err = s.Manager.PlayerIn(s.Ctx, room.ID, "psmirnov", AnySeat)
+	s.Require().Error(err)
+	s.Equal("room is locked", err.Error())
+
+	settings, err := NewRoomSettings(false, "", 3)
+	s.Require().NoError(err)
+	s.Require().NoError(s.Manager.UpdateSettings(s.Ctx, "evgsol", settings))
+
+	s.Require().NoError(s.Manager.PlayerOut(s.Ctx, "evgsol"))
+	room, err = s.Manager.GetOneForPlayer(s.Ctx, "solarka")
+	s.Require().NoError(err)
+	s.Equal("solarka", room.Host)
+	s.Equal(3, room.MaxPlayers())
+
+	var actions []string
+	for _, action := range room.HostLog {
+		actions = append(actions, action.Action)
+	}
+	s.Equal([]string{HostActionKick, HostActionLock, HostActionSettings, HostActionHost}, actions)
+
+	s.Require().NoError(s.Manager.CloseRoom(s.Ctx, "solarka"))
+	room, err = s.Manager.GetOneForPlayer(s.Ctx, "solarka")
+	s.Require().NoError(err)
+	s.Nil(room)
+
+	s.Run("Hostless room", func() {
+		room, err := s.DAO.Insert(s.Ctx, &Room{
+			Sides:        []RoomSideInfo{{Name: "bot", Bot: "heuristic"}, {}, {}, {}},
+			Status:       RoomStatusCreated,
+			PlayersCount: 1,
+			Hosted:       true,
+		})
+		s.Require().NoError(err)
+		defer s.DAO.Remove(s.Ctx, room.ID)
+
+		s.Require().NoError(s.Manager.PlayerIn(s.Ctx, room.ID, "pavel", AnySeat))
+		room, err = s.DAO.FindOneByID(s.Ctx, room.ID)
+		s.Require().NoError(err)
+		s.Equal("pavel", room.Host)
+	})
+}
+
+func (s *RoomSuite) TestChat() {
+	room, err := s.DAO.Insert(s.Ctx, &Room{
+		Sides: []RoomSideInfo{
+			{Name: "evgsol"},
+			{Name: "solarka"},
+			{Name: EMPTY_SIDE},
+			{Name: EMPTY_SIDE},
+		},
+		PlayersCount: 2,
+		Spectators:   []string{"psmirnov"},
+	})
+	s.Require().NoError(err)
+
+	filter, err := NewChatFilter([]string{"(?i)damn"})
+	s.Require().NoError(err)
+	s.Manager.SetChatFilter(filter)
+	defer s.Manager.SetChatFilter(nil)
+
+	s.Require().NoError(s.Manager.SendMessage(s.Ctx, room.ID, "evgsol", "hi"))
+	s.Require().NoError(s.Manager.SendMessage(s.Ctx, room.ID, "psmirnov", "Damn, a misere"))
+	s.Require().NoError(s.Manager.SendMessage(s.Ctx, room.ID, "solarka", "hello"))
+	s.Error(s.Manager.SendMessage(s.Ctx, room.ID, "kek", "hello"))
+
+	messages, err := s.Manager.GetMessages(s.Ctx, room.ID, "evgsol", ZeroRoomID(), 0)
+	s.Require().NoError(err)
+	s.Require().Len(messages, 2)
+	s.Equal("hi", messages[0].Text)
+
+	messages, err = s.Manager.GetMessages(s.Ctx, room.ID, "psmirnov", ZeroRoomID(), 2)
+	s.Require().NoError(err)
+	s.Require().Len(messages, 2)
+	s.Equal("****, a misere", messages[0].Text)
+	messages, err = s.Manager.GetMessages(s.Ctx, room.ID, "psmirnov", messages[0].ID, 2)
+	s.Require().NoError(err)
+	s.Require().Len(messages, 1)
+	s.Equal("hi", messages[0].Text)
+
+	s.Require().NoError(s.Manager.Mute(s.Ctx, "evgsol", "solarka", true))
+	s.Error(s.Manager.SendMessage(s.Ctx, room.ID, "solarka", "hello"))
+}
+
+func (s *RoomSuite) TestLobby() {
+	s.Require().NoError(s.Manager.CreateRoom(s.Ctx, "evgsol", nil))
+	room, err := s.Manager.GetOneForPlayer(s.Ctx, "evgsol")
+	s.Require().NoError(err)
+
+	_, _, err = s.Manager.Invite(s.Ctx, "solarka", "psmirnov")
+	s.Error(err)
+	_, _, err = s.Manager.Invite(s.Ctx, "evgsol", "solarka")
+	s.Require().NoError(err)
+	_, _, err = s.Manager.Invite(s.Ctx, "evgsol", "psmirnov")
+	s.Require().NoError(err)
+
+	invitations, err := s.Manager.Invitations(s.Ctx, "solarka")
+	s.Require().NoError(err)
+	s.Require().Len(invitations, 1)
+	s.Equal(room.ID.String(), invitations[0].RoomID)
+
+	s.Require().NoError(s.Manager.Join(s.Ctx, room.ID, "solarka", AnySeat, "", ""))
+	_, _, err = s.Manager.Invite(s.Ctx, "solarka", "pavel")
+	s.Error(err)
+	_, _, err = s.Manager.Invite(s.Ctx, "evgsol", "solarka")
+	s.Error(err)
+	s.Require().NoError(s.Manager.DeclineInvitation(s.Ctx, room.ID, "psmirnov"))
+	invitations, err = s.Manager.Invitations(s.Ctx, "psmirnov")
+	s.Require().NoError(err)
+	s.Empty(invitations)
+
+	lobby, err := s.Manager.Lobby(s.Ctx, []string{"evgsol", "psmirnov"})
+	s.Require().NoError(err)
+	s.Equal([]OnlinePlayer{
+		{Name: "evgsol", Status: PresenceInRoom, RoomID: room.ID.String()},
+		{Name: "psmirnov", Status: PresenceIdle},
+	}, lobby)
+}
+
+func (s *RoomSuite) TestMatchmaking() {
+	matchmaker := NewMatchmaker(s.Manager)
+	now := time.Now()
+	for _, name := range []string{"evgsol", "solarka"} {
+		s.Require().NoError(matchmaker.Enqueue(s.Ctx, MatchTicket{
+			Player: name, Convention: ConventionLeningrad, Players: 3, Rating: DefaultRating, Since: now,
+		}))
+	}
+	s.Equal(2, matchmaker.Status("solarka").Waiting)
+	s.Require().NoError(matchmaker.Leave("solarka"))
+	s.Error(matchmaker.Leave("solarka"))
+	s.Nil(matchmaker.Status("solarka").Ticket)
+
+	for _, name := range []string{"solarka", "psmirnov"} {
+		s.Require().NoError(matchmaker.Enqueue(s.Ctx, MatchTicket{
+			Player: name, Convention: ConventionLeningrad, Players: 3, Rating: DefaultRating, Since: now,
+		}))
+	}
+	s.Nil(matchmaker.Status("evgsol").Ticket)
+
+	room, err := s.Manager.GetOneForPlayer(s.Ctx, "psmirnov")
+	s.Require().NoError(err)
+	s.Require().NotNil(room)
+	s.Equal(RoomStatusReady, room.Status)
+	s.Equal(3, room.PlayersCount)
+	s.Equal(3, room.MaxPlayers())
+	s.Equal(ConventionLeningrad, room.Convention())
+
+	s.Error(matchmaker.Enqueue(s.Ctx, MatchTicket{
+		Player: "evgsol", Convention: ConventionLeningrad, Players: 3, Since: now,
+	}))
+
+	settings, err := NewRoomSettings(false, "", 3)
+	s.Require().NoError(err)
+	_, err = s.Manager.CreateReadyRoom(s.Ctx, []string{"pavel", "evgsol", "olga"}, settings)
+	s.Error(err)
+	room, err = s.Manager.GetOneForPlayer(s.Ctx, "pavel")
+	s.Require().NoError(err)
+	s.Nil(room)
+}
+
+func (s *RoomSuite) TestExpire() {
+	settings, err := NewRoomSettings(false, "", 3)
+	s.Require().NoError(err)
+	roomID, err := s.Manager.CreateReadyRoom(s.Ctx, []string{"evgsol", "solarka", "psmirnov"}, settings)
+	s.Require().NoError(err)
+	s.Require().NoError(s.Manager.Shuffle(s.Ctx, roomID, "evgsol"))
+
+	room, err := s.Manager.dao.FindOneByID(s.Ctx, roomID)
+	s.Require().NoError(err)
+	since := room.Game.DecisionSince
+	index, kind := room.Decision()
+	s.Require().Equal(DecisionBid, kind)
+	name := room.Sides[index].Name
+	// Mongo keeps the milliseconds of the decision time.
+	time.Sleep(2 * time.Millisecond)
+	s.Require().NoError(s.Manager.Bid(s.Ctx, roomID, name, "6S"))
+
+	s.Require().NoError(s.Manager.Expire(s.Ctx, roomID, since, name, kind, Action{Bid: BidPass}))
+	room, err = s.Manager.dao.FindOneByID(s.Ctx, roomID)
+	s.Require().NoError(err)
+	s.Equal(Bid("6S"), room.Game.Bids[len(room.Game.Bids)-1].Bid)
+}
+
+func (s *RoomSuite) TestMarkRated() {
+	s.Require().NoError(s.Manager.CreateRoom(s.Ctx, "evgsol", nil))
+	room, err := s.Manager.GetOneForPlayer(s.Ctx, "evgsol")
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.Manager.dao.MarkRated(s.Ctx, room.ID))
+	s.ErrorIs(s.Manager.dao.MarkRated(s.Ctx, room.ID), mgo.ErrNotFound)
+}
+
+func (s *RoomSuite) TestStats() {
+	deal := func(day int, players []string) *ArchivedDeal {
+		return &ArchivedDeal{
+			Finished:   time.Date(2021, 3, day, 12, 0, 0, 0, time.UTC),
+			Convention: ConventionLeningrad,
+			Balances:   []float64{10, -5, -5},
+			Result: DealResult{
+				Players:  players,
+				Tricks:   []int{6, 2, 2},
+				Contract: "6S",
+				Declarer: 0,
+				Whisted:  []bool{false, true, false},
+			},
+		}
+	}
+
+	for _, d := range []*ArchivedDeal{
+		deal(1, []string{"evgsol", "solarka", "psmirnov"}),
+		deal(1, []string{"evgsol", "solarka", "psmirnov"}),
+		deal(2, []string{"evgsol", "solarka", "pavel"}),
+		deal(3, []string{"evgsol", "olga", "pavel"}),
+	} {
+		for _, b := range DealBuckets(d) {
+			s.Require().NoError(s.Manager.stats.Add(s.Ctx, b))
+		}
+	}
+
+	report, err := s.Manager.Stats(s.Ctx, "evgsol", StatsFilter{})
+	s.Require().NoError(err)
+	s.Equal(4, report.Deals)
+	s.Equal(map[string]int{"6": 4}, report.Declared)
+	s.Equal(40.0, report.Balance[ConventionLeningrad])
+
+	report, err = s.Manager.Stats(s.Ctx, "evgsol", StatsFilter{Opponents: []string{"solarka"}})
+	s.Require().NoError(err)
+	s.Equal(3, report.Deals)
+
+	report, err = s.Manager.Stats(s.Ctx, "evgsol", StatsFilter{
+		From: time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC),
+	})
+	s.Require().NoError(err)
+	s.Equal(1, report.Deals)
+
+	report, err = s.Manager.Stats(s.Ctx, "solarka", StatsFilter{})
+	s.Require().NoError(err)
+	s.Equal(3, report.Whisted)
+	s.Equal(1.0, report.WhistFrequency)
+
+	played := deal(4, []string{"evgsol", "solarka", "psmirnov"})
+	played.ID = NewRoomID()
+	played.Bids = []BidInfo{{Player: "evgsol", Bid: "6S"}}
+	puzzle := deal(4, []string{"evgsol", "solarka", "psmirnov"})
+	puzzle.ID = NewRoomID()
+	s.Require().NoError(s.Manager.deals.Insert(s.Ctx, played))
+	s.Require().NoError(s.Manager.deals.Insert(s.Ctx, puzzle))
+	s.Require().NoError(s.Manager.BackfillStats(s.Ctx))
+	report, err = s.Manager.Stats(s.Ctx, "evgsol", StatsFilter{})
+	s.Require().NoError(err)
+	s.Equal(1, report.Deals)
+	s.Equal(10.0, report.Balance[ConventionLeningrad])
+}
+
+func (s *RoomSuite) TestTournament() {
+	tournaments := NewTournamentManager(s.Manager)
+	defer tournaments.dao.RemoveAll(s.Ctx)
+
+	id, err := tournaments.Create(s.Ctx, "evgsol", "Club championship", ConventionSochi, 2)
+	s.Require().NoError(err)
+	for _, name := range []string{"solarka", "psmirnov", "pavel", "olga"} {
+		s.Require().NoError(tournaments.Register(s.Ctx, id, name))
+	}
+	s.Error(tournaments.Register(s.Ctx, id, "olga"))
+	s.Error(tournaments.Start(s.Ctx, id, "evgsol"))
+
+	s.Require().NoError(tournaments.Register(s.Ctx, id, "ivan"))
+	s.Error(tournaments.Start(s.Ctx, id, "solarka"))
+	s.Require().NoError(tournaments.Start(s.Ctx, id, "evgsol"))
+	s.Error(tournaments.Register(s.Ctx, id, "maria"))
+
+	view, err := tournaments.Get(s.Ctx, id)
+	s.Require().NoError(err)
+	s.Equal(TournamentRunning, view.Status)
+	s.Require().Len(view.Tables, 2)
+	s.Empty(view.Report.Boards)
+
+	room, err := s.Manager.GetOneForPlayer(s.Ctx, "olga")
+	s.Require().NoError(err)
+	s.Equal(view.Tables[1].RoomID, room.ID)
+	s.Equal(RoomStatusReady, room.Status)
+	s.Require().NotNil(room.Duplicate)
+	s.Len(room.Duplicate.Boards, 2)
+	s.Equal(view.Tables[1].Players, room.Duplicate.Players)
+	s.Error(s.Manager.AddEntropy(s.Ctx, "olga", "entropy"))
+}
+
+func (s *RoomSuite) TestSwissTournament() {
+	tournaments := NewTournamentManager(s.Manager)
+	defer tournaments.dao.RemoveAll(s.Ctx)
+
+	id, err := tournaments.CreateSwiss(s.Ctx, "evgsol", "Monthly", ConventionSochi, 2)
+	s.Require().NoError(err)
+	for _, name := range []string{"solarka", "psmirnov", "pavel"} {
+		s.Require().NoError(tournaments.Register(s.Ctx, id, name))
+	}
+	s.Require().NoError(tournaments.Start(s.Ctx, id, "evgsol"))
+
+	view, err := tournaments.Get(s.Ctx, id)
+	s.Require().NoError(err)
+	s.Require().Len(view.Rounds, 1)
+	s.Equal([]string{"solarka"}, view.Rounds[0].Byes)
+	table := view.Rounds[0].Tables[0]
+
+	room, err := s.Manager.GetOne(s.Ctx, table.RoomID)
+	s.Require().NoError(err)
+	s.True(room.Settings.SinglePulka)
+	s.Empty(room.Host)
+	s.Error(s.Manager.CloseRoom(s.Ctx, table.Players[0]))
+	settings, err := NewRoomSettings(false, "", TournamentTableSize)
+	s.Require().NoError(err)
+	s.Error(s.Manager.UpdateSettings(s.Ctx, table.Players[0], settings))
+	room.Score = NewScoreSheet(table.Players, ConventionSochi, 10)
+	room.Score.Players[0].Mountain = 10
+	room.Score.Finished = true
+	s.Require().NoError(s.DAO.Update(s.Ctx, room))
+
+	view, err = tournaments.Get(s.Ctx, id)
+	s.Require().NoError(err)
+	s.False(view.Rounds[0].Tables[0].Finished)
+
+	stale, err := tournaments.dao.FindOneByID(s.Ctx, id)
+	s.Require().NoError(err)
+	s.Require().NoError(tournaments.CollectRunning(s.Ctx))
+	s.Require().NoError(tournaments.Collect(s.Ctx, stale))
+	view, err = tournaments.Get(s.Ctx, id)
+	s.Require().NoError(err)
+	s.True(view.Rounds[0].Tables[0].Finished)
+	s.Require().Len(view.Rounds, 2)
+	s.Equal(table.Players[0], view.Standings[3].Player)
+	s.Equal(1.0, view.Standings[2].Points)
+
+	s.Error(tournaments.NoShow(s.Ctx, id, "solarka", "pavel"))
+	s.Require().NoError(tournaments.NoShow(s.Ctx, id, "evgsol", view.Rounds[1].Tables[0].Players[0]))
+	view, err = tournaments.Get(s.Ctx, id)
+	s.Require().NoError(err)
+	s.Equal(TournamentRunning, view.Status)
+	s.True(view.Rounds[1].Tables[0].Finished)
+
+	s.Require().NoError(tournaments.CollectRunning(s.Ctx))
+	view, err = tournaments.Get(s.Ctx, id)
+	s.Require().NoError(err)
+	s.Equal(TournamentFinished, view.Status)
+	s.Len(view.Withdrawn, 1)
+	for _, name := range []string{"evgsol", "solarka", "psmirnov", "pavel"} {
+		room, err := s.Manager.GetOneForPlayer(s.Ctx, name)
+		s.Require().NoError(err)
+		s.Nil(room)
+	}
+}
+
+func (s *RoomSuite) TestMistakesStored() {
+	deal := &ArchivedDeal{
+		ID: NewRoomID(),
+		Hands: [][]Card{
+			parseCards("AS", "KS", "7H"),
+			parseCards("QS", "8H", "AD"),
+			parseCards("JS", "9H", "KD"),
+		},
+		Plays: []CenterCardInfo{
+			{Card: Card{SuitHearts, "7"}, Player: "a"},
+			{Card: Card{SuitHearts, "8"}, Player: "b"},
+			{Card: Card{SuitHearts, "9"}, Player: "c"},
+		},
+		Result: DealResult{
+			Players:  []string{"a", "b", "c"},
+			Tricks:   []int{1, 1, 1},
+			Contract: "6NT",
+		},
+	}
+	s.Require().NoError(s.Manager.deals.Insert(s.Ctx, deal))
+
+	_, err := s.Manager.Mistakes(s.Ctx, deal.ID, "d")
+	s.Error(err)
+
+	report, err := s.Manager.Mistakes(s.Ctx, deal.ID, "a")
+	s.Require().NoError(err)
+	s.Require().Len(report.Mistakes, 1)
+
+	stored, err := s.Manager.deals.FindOneByID(s.Ctx, deal.ID)
+	s.Require().NoError(err)
+	s.Require().NotNil(stored.Review)
+	s.Equal(report.Mistakes, stored.Review.Mistakes)
+	s.Equal(report.Lost, stored.Review.Lost)
+
+	again, err := s.Manager.Mistakes(s.Ctx, deal.ID, "b")
+	s.Require().NoError(err)
+	s.Equal(report.Mistakes, again.Mistakes)
+	s.Equal(deal.ID, again.Deal.ID)
+}
+
+func (s *RoomSuite) TestExpireProposals() {
+	now := time.Now()
+	expired, err := s.DAO.Insert(s.Ctx, &Room{
+		Sides:    []RoomSideInfo{{Name: "evgsol"}, {Name: "solarka"}, {}, {}},
+		Proposal: &Proposal{Kind: ProposalAbort, Proposer: "evgsol", Expires: now.Add(-time.Second)},
+	})
+	s.Require().NoError(err)
+	defer s.DAO.Remove(s.Ctx, expired.ID)
+	open, err := s.DAO.Insert(s.Ctx, &Room{
+		Sides:    []RoomSideInfo{{Name: "psmirnov"}, {Name: "pavel"}, {}, {}},
+		Proposal: &Proposal{Kind: ProposalAbort, Proposer: "psmirnov", Expires: now.Add(time.Minute)},
+	})
+	s.Require().NoError(err)
+	defer s.DAO.Remove(s.Ctx, open.ID)
+
+	s.Require().NoError(NewBotDriver(s.Manager).ExpireProposals(s.Ctx, now))
+
+	room, err := s.DAO.FindOneByID(s.Ctx, expired.ID)
+	s.Require().NoError(err)
+	s.Nil(room.Proposal)
+	s.Require().Len(room.History, 1)
+	s.Equal(OutcomeExpired, room.History[0].Outcome)
+
+	room, err = s.DAO.FindOneByID(s.Ctx, open.ID)
+	s.Require().NoError(err)
+	s.NotNil(room.Proposal)
+}
@@ -0,0 +1,269 @@
+package main
+
+type Convention string
+
+const (
+	ConventionSochi     Convention = "sochi"
+	ConventionLeningrad Convention = "leningrad"
+
+	DefaultPulkaSize = 10
+)
+
+func (c Convention) Valid() bool {
+	return c == ConventionSochi || c == ConventionLeningrad
+}
+
+var (
+	// ContractCosts are the pulka points of the contracts by level.
+	ContractCosts = map[int]int{6: 2, 7: 4, 8: 6, 9: 8, 10: 10}
+	// WhistRequirements are the tricks the whisters must take together.
+	WhistRequirements = map[int]int{6: 4, 7: 2, 8: 1, 9: 1, 10: 1}
+)
+
+// DealResult is the outcome of a deal, the slices are aligned with Players.
+// Contract is empty for all pass.
+type DealResult struct {
+	Players  []string `json:"players" bson:"players"`
+	Tricks   []int    `json:"tricks" bson:"tricks"`
+	Contract Bid      `json:"contract" bson:"contract"`
+	Declarer int      `json:"declarer" bson:"declarer"`
+	Whisted  []bool   `json:"whisted" bson:"whisted"`
+}
+
+func (r *Room) DealResult() DealResult {
+	res := DealResult{
+		Contract: r.Game.Contract,
+		Declarer: -1,
+	}
+	if r.Status == RoomStatusAllPass {
+		res.Contract = ""
+	}
+
+	whisters := r.Game.Whisters()
+	for i, index := range r.Game.Players {
+		name := r.Sides[index].Name
+		res.Players = append(res.Players, name)
+		res.Tricks = append(res.Tricks, r.Sides[index].Tricks)
+
+		whisted := false
+		for _, w := range whisters {
+			if w == name {
+				whisted = true
+			}
+		}
+		res.Whisted = append(res.Whisted, whisted)
+
+		if index == r.Game.Declarer {
+			res.Declarer = i
+		}
+	}
+
+	return res
+}
+
+// PlayerScore keeps the whists written by the player against every other
+// player of the sheet in the same order.
+type PlayerScore struct {
+	Player   string `json:"player" bson:"player"`
+	Pulka    int    `json:"pulka" bson:"pulka"`
+	Mountain int    `json:"mountain" bson:"mountain"`
+	Whists   []int  `json:"whists" bson:"whists"`
+}
+
+type ScoreSheet struct {
+	Convention Convention    `json:"convention" bson:"convention"`
+	PulkaSize  int           `json:"pulkaSize" bson:"pulkaSize"`
+	Players    []PlayerScore `json:"players" bson:"players"`
+	Deals      int           `json:"deals" bson:"deals"`
+	Finished   bool          `json:"finished" bson:"finished"`
+	Aborted    bool          `json:"aborted,omitempty" bson:"aborted,omitempty"`
+	Forfeit    string        `json:"forfeit,omitempty" bson:"forfeit,omitempty"`
+	Rated      bool          `json:"rated,omitempty" bson:"rated,omitempty"`
+}
+
+func NewScoreSheet(players []string, convention Convention, pulkaSize int) *ScoreSheet {
+	res := &ScoreSheet{
+		Convention: convention,
+		PulkaSize:  pulkaSize,
+	}
+	for _, p := range players {
+		res.Players = append(res.Players, PlayerScore{
+			Player: p,
+			Whists: make([]int, len(players)),
+		})
+	}
+
+	return res
+}
+
+func (s *ScoreSheet) index(player string) int {
+	for i, p := range s.Players {
+		if p.Player == player {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// addPulka writes the points to the pulka. What does not fit is written to
+// the pulkas of the others for ten whists a point, then taken off the
+// mountain.
+func (s *ScoreSheet) addPulka(p, points int) {
+	for points > 0 {
+		target := p
+		if s.Players[p].Pulka >= s.PulkaSize {
+			target = -1
+			for i := range s.Players {
+				if s.Players[i].Pulka < s.PulkaSize && (target == -1 || s.Players[i].Pulka < s.Players[target].Pulka) {
+					target = i
+				}
+			}
+		}
+
+		if target == -1 {
+			s.Players[p].Mountain -= points
+			if s.Players[p].Mountain < 0 {
+				s.Players[p].Mountain = 0
+			}
+			return
+		}
+
+		written := s.PulkaSize - s.Players[target].Pulka
+		if written > points {
+			written = points
+		}
+		s.Players[target].Pulka += written
+		if target != p {
+			s.Players[p].Whists[target] += written * 10
+		}
+		points -= written
+	}
+}
+
+func (s *ScoreSheet) failFactor() int {
+	if s.Convention == ConventionLeningrad {
+		return 2
+	}
+
+	return 1
+}
+
+func (s *ScoreSheet) Record(res DealResult) {
+	s.Deals++
+	defer func() {
+		s.Finished = s.Closed()
+	}()
+
+	players := make([]int, len(res.Players))
+	for i, name := range res.Players {
+		players[i] = s.index(name)
+	}
+
+	if res.Contract == "" {
+		for i, p := range players {
+			if res.Tricks[i] == 0 {
+				s.addPulka(p, 1)
+			} else {
+				s.Players[p].Mountain += res.Tricks[i]
+			}
+		}
+		return
+	}
+
+	d := players[res.Declarer]
+	declarerTricks := res.Tricks[res.Declarer]
+
+	if res.Contract.IsMisere() {
+		if declarerTricks == 0 {
+			s.addPulka(d, ContractCosts[10])
+		} else {
+			s.Players[d].Mountain += ContractCosts[10] * declarerTricks * s.failFactor()
+		}
+		return
+	}
+
+	level := res.Contract.Level()
+	cost := ContractCosts[level]
+
+	var whisters []int
+	for i := range res.Players {
+		if res.Whisted[i] {
+			whisters = append(whisters, i)
+		}
+	}
+
+	if len(whisters) == 0 {
+		s.addPulka(d, cost)
+		return
+	}
+
+	if declarerTricks >= level {
+		s.addPulka(d, cost)
+	} else {
+		under := level - declarerTricks
+		s.Players[d].Mountain += cost * under * s.failFactor()
+		for i, p := range players {
+			if i != res.Declarer {
+				s.Players[p].Whists[d] += cost * under
+			}
+		}
+	}
+
+	defenceTricks := 0
+	for i := range res.Players {
+		if i != res.Declarer {
+			defenceTricks += res.Tricks[i]
+		}
+	}
+
+	required := WhistRequirements[level]
+	if len(whisters) == 1 {
+		// The only whister plays for the passed defender too.
+		w := players[whisters[0]]
+		s.Players[w].Whists[d] += cost * defenceTricks
+		if defenceTricks < required {
+			s.Players[w].Mountain += cost * (required - defenceTricks)
+		}
+		return
+	}
+
+	share := (required + 1) / 2
+	for _, i := range whisters {
+		w := players[i]
+		s.Players[w].Whists[d] += cost * res.Tricks[i]
+		if defenceTricks < required && res.Tricks[i] < share {
+			s.Players[w].Mountain += cost * (share - res.Tricks[i])
+		}
+	}
+}
+
+func (s *ScoreSheet) Closed() bool {
+	for _, p := range s.Players {
+		if p.Pulka < s.PulkaSize {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Balances settles the sheet in whists: the mountains are turned into
+// whists against everybody, ten a point shared by the players.
+func (s *ScoreSheet) Balances() []float64 {
+	n := float64(len(s.Players))
+	total := 0
+	for _, p := range s.Players {
+		total += p.Mountain
+	}
+
+	res := make([]float64, len(s.Players))
+	for i, p := range s.Players {
+		res[i] = 10 * (float64(total) - n*float64(p.Mountain)) / n
+		for j := range s.Players {
+			res[i] += float64(p.Whists[j] - s.Players[j].Whists[i])
+		}
+	}
+
+	return res
+}
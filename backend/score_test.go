@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreSheetContract(t *testing.T) {
+	sheet := NewScoreSheet([]string{"a", "b", "c"}, ConventionSochi, 10)
+
+	sheet.Record(DealResult{
+		Players:  []string{"b", "c", "a"},
+		Tricks:   []int{6, 3, 1},
+		Contract: "6S",
+		Declarer: 0,
+		Whisted:  []bool{false, true, false},
+	})
+
+	assert.Equal(t, 2, sheet.Players[1].Pulka)
+	assert.Equal(t, []int{0, 8, 0}, sheet.Players[2].Whists)
+	assert.Equal(t, 0, sheet.Players[2].Mountain)
+
+	sheet.Record(DealResult{
+		Players:  []string{"a", "b", "c"},
+		Tricks:   []int{5, 4, 1},
+		Contract: "7H",
+		Declarer: 0,
+		Whisted:  []bool{false, true, true},
+	})
+
+	assert.Equal(t, 8, sheet.Players[0].Mountain)
+	assert.Equal(t, []int{8 + 16, 0, 0}, sheet.Players[1].Whists)
+	assert.Equal(t, []int{8 + 4, 8, 0}, sheet.Players[2].Whists)
+	assert.Equal(t, 0, sheet.Players[2].Mountain)
+}
+
+func TestScoreSheetAllPassAndMisere(t *testing.T) {
+	sheet := NewScoreSheet([]string{"a", "b", "c"}, ConventionLeningrad, 10)
+
+	sheet.Record(DealResult{
+		Players: []string{"a", "b", "c"},
+		Tricks:  []int{0, 4, 6},
+	})
+	assert.Equal(t, 1, sheet.Players[0].Pulka)
+	assert.Equal(t, 4, sheet.Players[1].Mountain)
+	assert.Equal(t, 6, sheet.Players[2].Mountain)
+
+	sheet.Record(DealResult{
+		Players:  []string{"a", "b", "c"},
+		Tricks:   []int{1, 4, 5},
+		Contract: BidMisere,
+		Declarer: 0,
+		Whisted:  []bool{false, false, false},
+	})
+	assert.Equal(t, 20, sheet.Players[0].Mountain)
+}
+
+func TestScoreSheetClosingAndBalances(t *testing.T) {
+	sheet := NewScoreSheet([]string{"a", "b", "c"}, ConventionSochi, 4)
+	sheet.Players[1].Pulka = 3
+
+	sheet.addPulka(0, 10)
+	assert.Equal(t, 4, sheet.Players[0].Pulka)
+	assert.Equal(t, 4, sheet.Players[1].Pulka)
+	assert.Equal(t, 4, sheet.Players[2].Pulka)
+	assert.Equal(t, []int{0, 10, 40}, sheet.Players[0].Whists)
+	assert.True(t, sheet.Closed())
+
+	sheet.Players[1].Mountain = 6
+	balances := sheet.Balances()
+	assert.InDelta(t, 0, balances[0]+balances[1]+balances[2], 1e-9)
+	assert.InDelta(t, 50+20, balances[0], 1e-9)
+	assert.InDelta(t, -10-40, balances[1], 1e-9)
+}
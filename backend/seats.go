@@ -0,0 +1,54 @@
+package main
+
+import "errors"
+
+// AnySeat lets PlayerIn choose the seat.
+const AnySeat = -1
+
+// SeatSwap is a proposal of From to swap the seats with To, waiting for To
+// to accept it.
+type SeatSwap struct {
+	From string `json:"from" bson:"from"`
+	To   string `json:"to" bson:"to"`
+}
+
+// BetweenDeals tells whether the seats may be changed: before the first deal
+// or after a finished one.
+func (r *Room) BetweenDeals() bool {
+	return r.Game == nil || r.Game.Finished
+}
+
+// SwapSeats exchanges the players of the sides, the cards and tricks of the
+// last deal stay at their sides while the clocks follow the players.
+func (r *Room) SwapSeats(first, second int) error {
+	if !r.BetweenDeals() {
+		return errors.New("wrong room status")
+	}
+
+	a, b := &r.Sides[first], &r.Sides[second]
+	a.Name, b.Name = b.Name, a.Name
+	a.Bot, b.Bot = b.Bot, a.Bot
+	a.Open, b.Open = b.Open, a.Open
+	if len(r.Clocks) == len(r.Sides) {
+		r.Clocks[first], r.Clocks[second] = r.Clocks[second], r.Clocks[first]
+	}
+	r.Swap = nil
+
+	return nil
+}
+
+// RelativeSeats returns the position of every side clockwise from the
+// player, the player's own side is 0.
+func (r *Room) RelativeSeats(playerName string) []int {
+	index := r.PlayerSideIndex(playerName)
+	if index == -1 || playerName == EMPTY_SIDE {
+		return nil
+	}
+
+	res := make([]int, len(r.Sides))
+	for i := range r.Sides {
+		res[i] = (i - index + len(r.Sides)) % len(r.Sides)
+	}
+
+	return res
+}
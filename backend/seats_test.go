@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwapSeats(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	room.Sides[1].Open = true
+	require.NoError(t, room.SwapSeats(0, 1))
+	assert.Equal(t, "solarka", room.Sides[0].Name)
+	assert.True(t, room.Sides[0].Open)
+	assert.Equal(t, []int{3, 0, 1, 2}, room.RelativeSeats("evgsol"))
+	assert.Nil(t, room.RelativeSeats(EMPTY_SIDE))
+
+	require.NoError(t, room.Shuffle("evgsol"))
+	assert.False(t, room.BetweenDeals())
+	assert.Error(t, room.SwapSeats(0, 1))
+
+	strategy := &HeuristicStrategy{}
+	for !room.Game.Finished {
+		index, kind := room.Decision()
+		require.NoError(t, room.Apply(room.Sides[index].Name, kind, strategy.Decide(room.ViewFor(index))))
+	}
+	assert.True(t, room.BetweenDeals())
+	require.NoError(t, room.SwapSeats(0, 1))
+	assert.Equal(t, "evgsol", room.Sides[0].Name)
+	require.NoError(t, room.Shuffle("evgsol"))
+	assert.False(t, room.BetweenDeals())
+}
+
+func TestSwapSeatsClocks(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	room.Settings = &RoomSettings{
+		MaxPlayers:  4,
+		TimeControl: &TimeControl{Increment: 10, Bank: 60, OnExpire: ExpireAuto},
+	}
+	room.Score = NewScoreSheet([]string{"evgsol", "solarka", "psmirnov"}, ConventionSochi, 10)
+	require.NoError(t, room.Shuffle("evgsol"))
+
+	strategy := &HeuristicStrategy{}
+	for !room.Game.Finished {
+		index, kind := room.Decision()
+		require.NoError(t, room.Apply(room.Sides[index].Name, kind, strategy.Decide(room.ViewFor(index))))
+	}
+	room.Clocks = []int{10000, 20000, 30000, 60000}
+
+	require.NoError(t, room.SwapSeats(0, 2))
+	assert.Equal(t, "psmirnov", room.Sides[0].Name)
+	assert.Equal(t, []int{30000, 20000, 10000, 60000}, room.Clocks)
+}
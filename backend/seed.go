@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sort"
+)
+
+const (
+	ServerSeedSize   = 32
+	MaxEntropyLength = 128
+)
+
+// DealSeed is the randomness behind a single deal. The server seed stays
+// secret until the deal is over, only its commitment is published.
+type DealSeed struct {
+	ServerSeed     string            `json:"-" bson:"serverSeed"`
+	Commitment     string            `json:"commitment" bson:"commitment"`
+	Entropy        map[string]string `json:"entropy" bson:"entropy"`
+	BuypackIndex   int               `json:"-" bson:"buypackIndex"`
+	PlayersIndexes []int             `json:"-" bson:"playersIndexes"`
+}
+
+// DealReveal contains everything needed to recompute a finished deal.
+type DealReveal struct {
+	ServerSeed     string            `json:"serverSeed" bson:"serverSeed"`
+	Commitment     string            `json:"commitment" bson:"commitment"`
+	Entropy        map[string]string `json:"entropy" bson:"entropy"`
+	BuypackIndex   int               `json:"buypackIndex" bson:"buypackIndex"`
+	PlayersIndexes []int             `json:"playersIndexes" bson:"playersIndexes"`
+}
+
+func NewDealSeed() (*DealSeed, error) {
+	raw := make([]byte, ServerSeedSize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	serverSeed := hex.EncodeToString(raw)
+	return &DealSeed{
+		ServerSeed: serverSeed,
+		Commitment: SeedCommitment(serverSeed),
+		Entropy:    map[string]string{},
+	}, nil
+}
+
+func SeedCommitment(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *DealSeed) AddEntropy(playerName, entropy string) error {
+	if len(entropy) == 0 || len(entropy) > MaxEntropyLength {
+		return errors.New("wrong entropy length")
+	}
+
+	if s.Entropy == nil {
+		s.Entropy = map[string]string{}
+	}
+	s.Entropy[playerName] = entropy
+	return nil
+}
+
+func (s *DealSeed) Reveal() DealReveal {
+	return DealReveal{
+		ServerSeed:     s.ServerSeed,
+		Commitment:     s.Commitment,
+		Entropy:        s.Entropy,
+		BuypackIndex:   s.BuypackIndex,
+		PlayersIndexes: s.PlayersIndexes,
+	}
+}
+
+// CombineSeeds mixes the server seed with the players' entropy:
+// sha256(serverSeed "\n" name ":" entropy "\n" ...), names sorted.
+func CombineSeeds(serverSeed string, entropy map[string]string) []byte {
+	var names []string
+	for name := range entropy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	h.Write([]byte(serverSeed))
+	h.Write([]byte("\n"))
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte(":"))
+		h.Write([]byte(entropy[name]))
+		h.Write([]byte("\n"))
+	}
+
+	return h.Sum(nil)
+}
+
+// seedStream is a deterministic generator: block i is sha256(seed || i).
+type seedStream struct {
+	seed    []byte
+	counter uint64
+	buffer  []byte
+}
+
+func (s *seedStream) uint32() uint32 {
+	if len(s.buffer) < 4 {
+		block := make([]byte, len(s.seed)+8)
+		copy(block, s.seed)
+		binary.BigEndian.PutUint64(block[len(s.seed):], s.counter)
+		s.counter++
+
+		sum := sha256.Sum256(block)
+		s.buffer = sum[:]
+	}
+
+	res := binary.BigEndian.Uint32(s.buffer[:4])
+	s.buffer = s.buffer[4:]
+	return res
+}
+
+// intn returns a uniform number in [0, n) using rejection sampling.
+func (s *seedStream) intn(n uint32) uint32 {
+	limit := ^uint32(0) - ^uint32(0)%n
+	for {
+		v := s.uint32()
+		if v < limit {
+			return v % n
+		}
+	}
+}
+
+func NewDeck() []Card {
+	var allCards []Card
+	for _, s := range AllSuits {
+		for _, r := range AllRanks {
+			allCards = append(allCards, Card{
+				Suit: s,
+				Rank: r,
+			})
+		}
+	}
+
+	return allCards
+}
+
+// ShuffledDeck applies Fisher-Yates to NewDeck driven by the seed.
+func ShuffledDeck(seed []byte) []Card {
+	cards := NewDeck()
+	stream := &seedStream{seed: seed}
+	for i := len(cards) - 1; i > 0; i-- {
+		j := stream.intn(uint32(i + 1))
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+
+	return cards
+}
+
+// DealCards gives two first cards to the buypack and ten cards to every
+// player in order, returning the hands by side index.
+func DealCards(deck []Card, buypackIndex int, playersIndexes []int) [][]Card {
+	hands := make([][]Card, 4)
+	hands[buypackIndex] = append([]Card{}, deck[:2]...)
+	for i, index := range playersIndexes {
+		hand := append([]Card{}, deck[2+i*10:2+(i+1)*10]...)
+		sort.Slice(hand, func(l, r int) bool {
+			return hand[l].Less(hand[r])
+		})
+		hands[index] = hand
+	}
+
+	return hands
+}
+
+func VerifyDeal(reveal DealReveal) ([][]Card, error) {
+	if SeedCommitment(reveal.ServerSeed) != reveal.Commitment {
+		return nil, errors.New("commitment does not match server seed")
+	}
+
+	if len(reveal.PlayersIndexes) != 3 {
+		return nil, errors.New("wrong players count")
+	}
+
+	seen := map[int]bool{reveal.BuypackIndex: true}
+	for _, index := range append([]int{reveal.BuypackIndex}, reveal.PlayersIndexes...) {
+		if index < 0 || index > 3 {
+			return nil, errors.New("wrong side index")
+		}
+	}
+	for _, index := range reveal.PlayersIndexes {
+		if seen[index] {
+			return nil, errors.New("wrong side index")
+		}
+		seen[index] = true
+	}
+
+	deck := ShuffledDeck(CombineSeeds(reveal.ServerSeed, reveal.Entropy))
+	return DealCards(deck, reveal.BuypackIndex, reveal.PlayersIndexes), nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShuffledDeckIsDeterministicPermutation(t *testing.T) {
+	seed := CombineSeeds("server", map[string]string{"evgsol": "abc", "solarka": "def"})
+
+	deck := ShuffledDeck(seed)
+	require.Len(t, deck, 32)
+	assert.Equal(t, deck, ShuffledDeck(seed))
+	assert.ElementsMatch(t, NewDeck(), deck)
+	assert.NotEqual(t, NewDeck(), deck)
+
+	other := ShuffledDeck(CombineSeeds("server", map[string]string{"evgsol": "abc", "solarka": "deg"}))
+	assert.NotEqual(t, deck, other)
+}
+
+func TestVerifyDeal(t *testing.T) {
+	seed, err := NewDealSeed()
+	require.NoError(t, err)
+	require.NoError(t, seed.AddEntropy("evgsol", "my lucky numbers"))
+	require.Error(t, seed.AddEntropy("solarka", ""))
+
+	seed.BuypackIndex = 3
+	seed.PlayersIndexes = []int{0, 1, 2}
+	expected := DealCards(ShuffledDeck(CombineSeeds(seed.ServerSeed, seed.Entropy)), 3, []int{0, 1, 2})
+
+	hands, err := VerifyDeal(seed.Reveal())
+	require.NoError(t, err)
+	assert.Equal(t, expected, hands)
+	assert.Len(t, hands[3], 2)
+	assert.Len(t, hands[0], 10)
+
+	broken := seed.Reveal()
+	broken.ServerSeed += "0"
+	_, err = VerifyDeal(broken)
+	assert.Error(t, err)
+}
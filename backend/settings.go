@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const InviteTokenSize = 16
+
+// RoomSettings are chosen by the creator of the room. Rooms without them are
+// public for four players.
+type RoomSettings struct {
+	Private      bool         `json:"private" bson:"private"`
+	MaxPlayers   int          `json:"maxPlayers" bson:"maxPlayers"`
+	PasswordHash []byte       `json:"-" bson:"passwordHash,omitempty"`
+	Invited      []string     `json:"invited,omitempty" bson:"invited,omitempty"`
+	InviteTokens []string     `json:"-" bson:"inviteTokens,omitempty"`
+	GraceSeconds int          `json:"graceSeconds,omitempty" bson:"graceSeconds,omitempty"`
+	TimeControl  *TimeControl `json:"timeControl,omitempty" bson:"timeControl,omitempty"`
+	Convention   Convention   `json:"convention,omitempty" bson:"convention,omitempty"`
+	Stake        int          `json:"stake,omitempty" bson:"stake,omitempty"`
+	// SinglePulka rooms keep the finished pulka instead of starting a new
+	// one, its result is collected by the tournament.
+	SinglePulka bool `json:"singlePulka,omitempty" bson:"singlePulka,omitempty"`
+}
+
+func NewRoomSettings(private bool, password string, maxPlayers int) (*RoomSettings, error) {
+	if maxPlayers == 0 {
+		maxPlayers = 4
+	}
+	if maxPlayers != 3 && maxPlayers != 4 {
+		return nil, errors.New("wrong max players")
+	}
+
+	res := &RoomSettings{
+		Private:    private,
+		MaxPlayers: maxPlayers,
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		res.PasswordHash = hash
+	}
+
+	return res, nil
+}
+
+// update returns the settings with the ones the host changes, the
+// invitations and the settings fixed at the creation are kept.
+func (s *RoomSettings) update(changed *RoomSettings) *RoomSettings {
+	res := *s
+	res.Private = changed.Private
+	res.PasswordHash = changed.PasswordHash
+	res.MaxPlayers = changed.MaxPlayers
+	res.GraceSeconds = changed.GraceSeconds
+	res.TimeControl = changed.TimeControl
+
+	return &res
+}
+
+func NewInviteToken() (string, error) {
+	raw := make([]byte, InviteTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(raw), nil
+}
+
+func (r *Room) MaxPlayers() int {
+	if r.Settings == nil {
+		return 4
+	}
+
+	return r.Settings.MaxPlayers
+}
+
+// Convention of the pulkas played in the room, Sochi by default.
+func (r *Room) Convention() Convention {
+	if r.Settings == nil || r.Settings.Convention == "" {
+		return ConventionSochi
+	}
+
+	return r.Settings.Convention
+}
+
+func (r *Room) IsPrivate() bool {
+	return r.Settings != nil && r.Settings.Private
+}
+
+func (r *Room) HasPassword() bool {
+	return r.Settings != nil && len(r.Settings.PasswordHash) > 0
+}
+
+func (r *Room) IsInvited(playerName string) bool {
+	if r.Settings == nil {
+		return false
+	}
+
+	for _, name := range r.Settings.Invited {
+		if name == playerName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// VisibleTo tells whether the room is listed for the user.
+func (r *Room) VisibleTo(playerName string) bool {
+	if r.Puzzle != nil {
+		return false
+	}
+
+	return !r.IsPrivate() || r.IsInvited(playerName) || (playerName != "" && r.PlayerSideIndex(playerName) != -1)
+}
+
+// CheckAccess lets in the invited users and the holders of an invite token
+// without the password, the others only into the public rooms.
+func (r *Room) CheckAccess(playerName, password, token string) error {
+	if r.IsInvited(playerName) {
+		return nil
+	}
+
+	if r.Settings != nil && token != "" {
+		for _, t := range r.Settings.InviteTokens {
+			if t == token {
+				return nil
+			}
+		}
+		return errors.New("wrong invite token")
+	}
+
+	if r.IsPrivate() {
+		return errors.New("room is private")
+	}
+
+	if r.HasPassword() {
+		if err := bcrypt.CompareHashAndPassword(r.Settings.PasswordHash, []byte(password)); err != nil {
+			return errors.New("wrong password")
+		}
+	}
+
+	return nil
+}
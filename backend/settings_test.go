@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomSettings(t *testing.T) {
+	_, err := NewRoomSettings(false, "", 2)
+	require.Error(t, err)
+
+	room := &Room{Sides: []RoomSideInfo{{Name: "evgsol"}, {Name: EMPTY_SIDE}, {Name: EMPTY_SIDE}, {Name: EMPTY_SIDE}}}
+	require.Equal(t, 4, room.MaxPlayers())
+	require.True(t, room.VisibleTo(""))
+	require.NoError(t, room.CheckAccess("solarka", "", ""))
+
+	room.Settings, err = NewRoomSettings(false, "secret", 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, room.MaxPlayers())
+	require.True(t, room.VisibleTo(""))
+	require.Error(t, room.CheckAccess("solarka", "", ""))
+	require.NoError(t, room.CheckAccess("solarka", "secret", ""))
+
+	room.Settings.Private = true
+	room.Settings.Invited = []string{"psmirnov"}
+	room.Settings.InviteTokens = []string{"token"}
+	require.False(t, room.VisibleTo(""))
+	require.False(t, room.VisibleTo("solarka"))
+	require.True(t, room.VisibleTo("evgsol"))
+	require.True(t, room.VisibleTo("psmirnov"))
+	require.Error(t, room.CheckAccess("solarka", "secret", ""))
+	require.Error(t, room.CheckAccess("solarka", "", "other"))
+	require.NoError(t, room.CheckAccess("solarka", "", "token"))
+	require.NoError(t, room.CheckAccess("psmirnov", "", ""))
+}
+
+func TestUpdateRoomSettings(t *testing.T) {
+	current := &RoomSettings{
+		MaxPlayers:   4,
+		Invited:      []string{"psmirnov"},
+		GraceSeconds: 30,
+		TimeControl:  &TimeControl{Increment: 10, Bank: 60, OnExpire: ExpireAuto},
+		Convention:   ConventionLeningrad,
+		Stake:        5,
+		SinglePulka:  true,
+	}
+	changed, err := NewRoomSettings(true, "", 3)
+	require.NoError(t, err)
+	changed.TimeControl = &TimeControl{Increment: 10, Bank: 60, OnExpire: ExpireAuto}
+
+	updated := current.update(changed)
+	require.True(t, updated.Private)
+	require.Equal(t, 3, updated.MaxPlayers)
+	require.Zero(t, updated.GraceSeconds)
+	require.True(t, updated.TimeControl.Equal(current.TimeControl))
+	require.Equal(t, []string{"psmirnov"}, updated.Invited)
+	require.Equal(t, ConventionLeningrad, updated.Convention)
+	require.Equal(t, 5, updated.Stake)
+	require.True(t, updated.SinglePulka)
+
+	require.False(t, changed.TimeControl.Equal(nil))
+	require.True(t, (*TimeControl)(nil).Equal(nil))
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// DDPosition is a position with all the hands open. Seats are numbered in
+// the order of play, Trick holds the cards played to the current trick
+// starting from Leader. Talon holds the buypack cards still to lead the
+// tricks of all pass, such tricks are started by FirstHand.
+type DDPosition struct {
+	Hands     [][]Card `json:"hands"`
+	Trump     Suit     `json:"trump"`
+	Leader    int      `json:"leader"`
+	Trick     []Card   `json:"trick"`
+	Talon     []Card   `json:"talon"`
+	FirstHand int      `json:"firstHand"`
+}
+
+func cardBit(c Card) int {
+	return c.suitNumber()*8 + c.rankNumber()
+}
+
+func bitCard(b int) Card {
+	return Card{
+		Suit: AllSuits[b/8],
+		Rank: AllRanks[b%8],
+	}
+}
+
+func suitMask(suit int) uint32 {
+	return 0xff << (suit * 8)
+}
+
+func suitIndex(s Suit) int {
+	return Card{Suit: s}.suitNumber()
+}
+
+type ddKey struct {
+	hands  [3]uint32
+	leader int8
+	talon  int8
+}
+
+type ddBounds struct {
+	lo, hi int8
+}
+
+// ddSolver counts the tricks of the target seat: the target maximizes them
+// unless minimize is set, the others play against it.
+type ddSolver struct {
+	hands     [3]uint32
+	trump     int
+	talon     []int
+	firstHand int
+	target    int
+	minimize  bool
+	table     map[ddKey]ddBounds
+}
+
+func newDDSolver(pos DDPosition, target int, minimize bool) (*ddSolver, error) {
+	if len(pos.Hands) != 3 {
+		return nil, errors.New("wrong players count")
+	}
+	if target < 0 || target > 2 || pos.Leader < 0 || pos.Leader > 2 {
+		return nil, errors.New("wrong seat")
+	}
+
+	s := &ddSolver{
+		trump:     -1,
+		firstHand: pos.FirstHand,
+		target:    target,
+		minimize:  minimize,
+		table:     map[ddKey]ddBounds{},
+	}
+	if pos.Trump != SuitNoTrump && pos.Trump != "" {
+		s.trump = suitIndex(pos.Trump)
+	}
+
+	seen := uint32(0)
+	add := func(c Card) (uint32, error) {
+		if c.suitNumber() > 3 || c.rankNumber() > 7 {
+			return 0, errors.New("unknown card")
+		}
+		b := uint32(1) << cardBit(c)
+		if seen&b != 0 {
+			return 0, errors.New("duplicate card")
+		}
+		seen |= b
+		return b, nil
+	}
+
+	for i, hand := range pos.Hands {
+		for _, c := range hand {
+			b, err := add(c)
+			if err != nil {
+				return nil, err
+			}
+			s.hands[i] |= b
+		}
+	}
+	for _, c := range append(append([]Card{}, pos.Trick...), pos.Talon...) {
+		if _, err := add(c); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range pos.Talon {
+		s.talon = append(s.talon, cardBit(c))
+	}
+
+	return s, nil
+}
+
+func (s *ddSolver) maximizes(seat int) bool {
+	return (seat == s.target) != s.minimize
+}
+
+// legal returns the cards of the hand allowed when the suit is led.
+func (s *ddSolver) legal(hand uint32, lead int) uint32 {
+	if lead < 0 {
+		return hand
+	}
+	if follow := hand & suitMask(lead); follow != 0 {
+		return follow
+	}
+	if s.trump >= 0 {
+		if trumps := hand & suitMask(s.trump); trumps != 0 {
+			return trumps
+		}
+	}
+	return hand
+}
+
+// candidates drops the cards equivalent to a higher one of the same set:
+// no card between them is still in a hand or in the trick.
+func (s *ddSolver) candidates(moves, live uint32) []int {
+	var res []int
+	for suit := 0; suit < 4; suit++ {
+		previous := false
+		for b := suit*8 + 7; b >= suit*8; b-- {
+			bit := uint32(1) << b
+			switch {
+			case moves&bit != 0:
+				if !previous {
+					res = append(res, b)
+				}
+				previous = true
+			case live&bit != 0:
+				previous = false
+			}
+		}
+	}
+
+	return res
+}
+
+// winner returns the position of the winning card among the trick cards,
+// the talon card only sets the led suit.
+func (s *ddSolver) winner(cards []int, lead int) int {
+	best := -1
+	for i, b := range cards {
+		suit := b / 8
+		if best == -1 {
+			if lead < 0 || suit == lead || suit == s.trump {
+				best = i
+			}
+			continue
+		}
+		bestSuit := cards[best] / 8
+		if suit == bestSuit && b > cards[best] {
+			best = i
+		} else if suit != bestSuit && suit == s.trump {
+			best = i
+		}
+	}
+
+	if best != -1 {
+		return best
+	}
+
+	// Nobody followed the talon: the first card leads.
+	best = 0
+	for i, b := range cards {
+		if b/8 == cards[0]/8 && b > cards[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// key is the position with the ranks of every suit counted among the cards
+// still in the hands only, so the positions differing by the played cards
+// share the table entry.
+func (s *ddSolver) key(leader int) ddKey {
+	res := ddKey{leader: int8(leader), talon: int8(len(s.talon))}
+	live := s.hands[0] | s.hands[1] | s.hands[2]
+	for suit := 0; suit < 4; suit++ {
+		rank := suit * 8
+		for b := suit * 8; b < suit*8+8; b++ {
+			bit := uint32(1) << b
+			if live&bit == 0 {
+				continue
+			}
+			for i := range s.hands {
+				if s.hands[i]&bit != 0 {
+					res.hands[i] |= uint32(1) << rank
+				}
+			}
+			rank++
+		}
+	}
+
+	return res
+}
+
+// trick searches from the start of a trick, the result is the number of
+// the target's tricks from now on.
+func (s *ddSolver) trick(leader, alpha, beta int) int {
+	left := bits.OnesCount32(s.hands[leader])
+	if left == 0 {
+		return 0
+	}
+
+	talonCard := -1
+	if len(s.talon) > 0 {
+		leader = s.firstHand
+		talonCard = s.talon[0]
+	}
+
+	key := s.key(leader)
+	bounds, ok := s.table[key]
+	if ok && bounds.lo == bounds.hi {
+		return int(bounds.lo)
+	}
+	if !ok {
+		bounds = ddBounds{lo: 0, hi: int8(left)}
+	}
+	if int(bounds.lo) >= beta {
+		return int(bounds.lo)
+	}
+	if int(bounds.hi) <= alpha {
+		return int(bounds.hi)
+	}
+	a, b := alpha, beta
+	if int(bounds.lo) > a {
+		a = int(bounds.lo)
+	}
+	if int(bounds.hi) < b {
+		b = int(bounds.hi)
+	}
+
+	var talon []int
+	lead := -1
+	if talonCard >= 0 {
+		talon = s.talon
+		s.talon = s.talon[1:]
+		lead = talonCard / 8
+	}
+
+	v := s.play(leader, leader, lead, nil, uint32(0), a, b)
+
+	if talon != nil {
+		s.talon = talon
+	}
+
+	if v <= a {
+		bounds.hi = int8(v)
+	} else if v >= b {
+		bounds.lo = int8(v)
+	} else {
+		bounds.lo, bounds.hi = int8(v), int8(v)
+	}
+	s.table[key] = bounds
+
+	return v
+}
+
+// play searches the move of the seat inside a trick.
+func (s *ddSolver) play(seat, leader, lead int, cards []int, trickMask uint32, alpha, beta int) int {
+	hand := s.hands[seat]
+	live := s.hands[0] | s.hands[1] | s.hands[2] | trickMask
+	moves := s.candidates(s.legal(hand, lead), live)
+
+	maximize := s.maximizes(seat)
+	best := -1
+	for _, m := range moves {
+		bit := uint32(1) << m
+		s.hands[seat] &^= bit
+
+		nextLead := lead
+		if nextLead < 0 {
+			nextLead = m / 8
+		}
+		played := append(cards, m)
+
+		var v int
+		if len(played) == 3 {
+			w := (leader + s.winner(played, lead)) % 3
+			won := 0
+			if w == s.target {
+				won = 1
+			}
+			v = won + s.trick(w, alpha-won, beta-won)
+		} else {
+			v = s.play((seat+1)%3, leader, nextLead, played, trickMask|bit, alpha, beta)
+		}
+
+		s.hands[seat] |= bit
+
+		if maximize {
+			if v > best {
+				best = v
+			}
+			if best > alpha {
+				alpha = best
+			}
+		} else {
+			if best == -1 || v < best {
+				best = v
+			}
+			if best < beta {
+				beta = best
+			}
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+
+	return best
+}
+
+// solve returns the target's tricks from the position on, including the
+// current trick. The tricks are found by the binary search with the null
+// windows, which cut much more than a full one and share the table.
+func (s *ddSolver) solve(pos DDPosition) int {
+	lo, hi := 0, bits.OnesCount32(s.hands[0]|s.hands[1]|s.hands[2])/3+1
+	for lo < hi {
+		g := (lo + hi + 1) / 2
+		if s.search(pos, g-1, g) >= g {
+			lo = g
+		} else {
+			hi = g - 1
+		}
+	}
+
+	return lo
+}
+
+func (s *ddSolver) search(pos DDPosition, alpha, beta int) int {
+	if len(pos.Trick) == 0 {
+		return s.trick(pos.Leader, alpha, beta)
+	}
+
+	lead := -1
+	var cards []int
+	var mask uint32
+	leader := pos.Leader
+	talon := s.talon
+	if len(s.talon) > 0 {
+		// The talon card of the current trick is the first one.
+		lead = s.talon[0] / 8
+		s.talon = s.talon[1:]
+	}
+	for _, c := range pos.Trick {
+		b := cardBit(c)
+		cards = append(cards, b)
+		mask |= uint32(1) << b
+		if lead < 0 {
+			lead = b / 8
+		}
+	}
+
+	v := s.play((leader+len(cards))%3, leader, lead, cards, mask, alpha, beta)
+	s.talon = talon
+
+	return v
+}
+
+// SolveTricks returns the tricks the target seat takes from the position
+// with the best play of everybody: the target maximizes them, or minimizes
+// them for misere and all pass, and the others play against it.
+func SolveTricks(pos DDPosition, target int, minimize bool) (int, error) {
+	s, err := newDDSolver(pos, target, minimize)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.solve(pos), nil
+}
+
+// Turn returns the seat to play.
+func (p DDPosition) Turn() int {
+	return (p.Leader + len(p.Trick)) % 3
+}
+
+func (p DDPosition) center() []CenterCardInfo {
+	var res []CenterCardInfo
+	if len(p.Talon) > 0 {
+		res = append(res, CenterCardInfo{Card: p.Talon[0]})
+	}
+	for _, c := range p.Trick {
+		res = append(res, CenterCardInfo{Card: c, Player: "-"})
+	}
+
+	return res
+}
+
+// Legal returns the cards the seat to play may play.
+func (p DDPosition) Legal() []Card {
+	hand := p.Hands[p.Turn()]
+	var res []Card
+	for _, i := range LegalCards(hand, p.center(), p.Trump) {
+		res = append(res, hand[i])
+	}
+
+	return res
+}
+
+// Play returns the position after the card of the seat to play and the seat
+// that took the trick, -1 while the trick goes on.
+func (p DDPosition) Play(card Card) (DDPosition, int) {
+	seat := p.Turn()
+	next := p
+	next.Hands = make([][]Card, len(p.Hands))
+	for i, hand := range p.Hands {
+		if i == seat {
+			hand = removeCards(hand, []Card{card})
+		}
+		next.Hands[i] = append([]Card{}, hand...)
+	}
+	next.Trick = append(append([]Card{}, p.Trick...), card)
+	if len(next.Trick) < 3 {
+		return next, -1
+	}
+
+	center := next.center()
+	offset := len(center) - 3
+	winner := (p.Leader + TrickWinner(center, func(c CenterCardInfo) bool { return c.Player != "" }, p.Trump) - offset) % 3
+
+	next.Trick = nil
+	next.Leader = winner
+	if len(next.Talon) > 0 {
+		next.Talon = next.Talon[1:]
+	}
+	if len(next.Talon) > 0 {
+		next.Leader = next.FirstHand
+	}
+
+	return next, winner
+}
+
+// OpenPosition returns the position of the current deal of the room, ok is
+// false while some of the cards are hidden.
+func (r *Room) OpenPosition() (DDPosition, bool) {
+	if r.Game == nil || r.Game.Finished {
+		return DDPosition{}, false
+	}
+
+	seats := map[string]int{}
+	pos := DDPosition{Trump: r.Trump()}
+	for seat, index := range r.Game.Players {
+		for _, c := range r.Sides[index].Cards {
+			if c == UnknownCard {
+				return DDPosition{}, false
+			}
+		}
+		pos.Hands = append(pos.Hands, append([]Card{}, r.Sides[index].Cards...))
+		seats[r.Sides[index].Name] = seat
+		if index == r.Game.Turn {
+			pos.Leader = seat
+		}
+	}
+
+	for _, c := range r.Center {
+		if !r.isPlayerCard(c) {
+			pos.Talon = append(pos.Talon, c.Card)
+			continue
+		}
+		if len(pos.Trick) == 0 {
+			pos.Leader = seats[c.Player]
+		}
+		pos.Trick = append(pos.Trick, c.Card)
+	}
+
+	if r.Status == RoomStatusAllPass {
+		for _, c := range r.Sides[r.BuypackIndex].Cards {
+			if c == UnknownCard {
+				return DDPosition{}, false
+			}
+			pos.Talon = append(pos.Talon, c)
+		}
+	}
+
+	return pos, true
+}
+
+// BestMove returns the index of the card the solver prefers among the legal
+// ones of the side, ok is false while some of the cards are hidden.
+func (r *Room) BestMove(sideIndex int, legal []int) (int, bool) {
+	pos, ok := r.OpenPosition()
+	if !ok || len(legal) == 0 {
+		return -1, false
+	}
+
+	seat := -1
+	for i, index := range r.Game.Players {
+		if index == sideIndex {
+			seat = i
+		}
+	}
+	if seat != pos.Turn() {
+		return -1, false
+	}
+
+	target, minimize := seat, true
+	if r.Status != RoomStatusAllPass {
+		for i, index := range r.Game.Players {
+			if index == r.Game.Declarer {
+				target = i
+			}
+		}
+		minimize = r.Game.Contract.IsMisere()
+	}
+	maximize := (seat == target) != minimize
+
+	best, bestValue := -1, 0
+	for _, i := range legal {
+		next, winner := pos.Play(r.Sides[sideIndex].Cards[i])
+		v, err := SolveTricks(next, target, minimize)
+		if err != nil {
+			return -1, false
+		}
+		if winner == target {
+			v++
+		}
+		if best == -1 || (maximize && v > bestValue) || (!maximize && v < bestValue) {
+			best, bestValue = i, v
+		}
+	}
+
+	return best, true
+}
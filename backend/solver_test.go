@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseCards(s ...string) []Card {
+	var res []Card
+	for _, c := range s {
+		res = append(res, Card{Suit: Suit(c[len(c)-1:]), Rank: c[:len(c)-1]})
+	}
+
+	return res
+}
+
+// bruteTricks plays out every legal line with the rules of the rooms.
+func bruteTricks(hands [][]Card, trump Suit, leader, target int, minimize bool) int {
+	if len(hands[leader]) == 0 {
+		return 0
+	}
+
+	var rec func(seat int, center []CenterCardInfo) int
+	rec = func(seat int, center []CenterCardInfo) int {
+		if len(center) == 3 {
+			all := func(CenterCardInfo) bool { return true }
+			w := (leader + TrickWinner(center, all, trump)) % 3
+			won := 0
+			if w == target {
+				won = 1
+			}
+			return won + bruteTricks(hands, trump, w, target, minimize)
+		}
+
+		hand := hands[seat]
+		best := -1
+		for _, i := range LegalCards(hand, center, trump) {
+			c := hand[i]
+			hands[seat] = append(append([]Card{}, hand[:i]...), hand[i+1:]...)
+			v := rec((seat+1)%3, append(center, CenterCardInfo{Card: c}))
+			hands[seat] = hand
+
+			if best == -1 || ((seat == target) != minimize && v > best) || ((seat == target) == minimize && v < best) {
+				best = v
+			}
+		}
+		return best
+	}
+
+	return rec(leader, nil)
+}
+
+// brutePosition plays out every legal line of the position, the talon
+// included.
+func brutePosition(pos DDPosition, target int, minimize bool) int {
+	seat := pos.Turn()
+	if len(pos.Hands[seat]) == 0 {
+		return 0
+	}
+
+	best := -1
+	for _, c := range pos.Legal() {
+		next, winner := pos.Play(c)
+		v := brutePosition(next, target, minimize)
+		if winner == target {
+			v++
+		}
+		if best == -1 || ((seat == target) != minimize && v > best) || ((seat == target) == minimize && v < best) {
+			best = v
+		}
+	}
+
+	return best
+}
+
+func TestSolverSmall(t *testing.T) {
+	hands := [][]Card{
+		parseCards("AS", "KS", "7H"),
+		parseCards("QS", "8H", "AD"),
+		parseCards("JS", "9H", "KD"),
+	}
+
+	pos := DDPosition{Hands: hands, Trump: SuitNoTrump}
+	tricks, err := SolveTricks(pos, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, tricks)
+
+	pos.Trump = SuitHearts
+	pos.Leader = 1
+	tricks, err = SolveTricks(pos, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, bruteTricks(hands, SuitHearts, 1, 0, false), tricks)
+
+	_, err = SolveTricks(DDPosition{Hands: [][]Card{hands[0], hands[0], hands[1]}}, 0, false)
+	assert.Error(t, err)
+}
+
+func TestSolverMatchesBruteForce(t *testing.T) {
+	for i := 0; i < 30; i++ {
+		deck := ShuffledDeck([]byte(fmt.Sprintf("solver-%d", i)))
+		hands := DealCards(deck, 0, []int{1, 2, 3})[1:]
+		for j := range hands {
+			hands[j] = hands[j][:4]
+		}
+		trump := AllSuits[i%4]
+		if i%5 == 0 {
+			trump = SuitNoTrump
+		}
+
+		pos := DDPosition{Hands: hands, Trump: trump, Leader: i % 3}
+		for _, minimize := range []bool{false, true} {
+			tricks, err := SolveTricks(pos, 0, minimize)
+			require.NoError(t, err)
+			require.Equal(t, bruteTricks(hands, trump, i%3, 0, minimize), tricks, "deal %d minimize %v", i, minimize)
+		}
+	}
+
+	for i := 0; i < 60; i++ {
+		deck := ShuffledDeck([]byte(fmt.Sprintf("talon-%d", i)))
+		hands := DealCards(deck, 0, []int{1, 2, 3})
+		pos := DDPosition{Trump: SuitNoTrump, Talon: hands[0], FirstHand: i % 3, Leader: i % 3}
+		for _, hand := range hands[1:] {
+			pos.Hands = append(pos.Hands, hand[:4])
+		}
+		for seat := 0; seat < 3; seat++ {
+			tricks, err := SolveTricks(pos, seat, true)
+			require.NoError(t, err)
+			require.Equal(t, brutePosition(pos, seat, true), tricks, "talon %d seat %d", i, seat)
+		}
+	}
+}
+
+func TestSolverFullDeal(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		deck := ShuffledDeck([]byte(fmt.Sprintf("full-%d", i)))
+		hands := DealCards(deck, 0, []int{1, 2, 3})
+		pos := DDPosition{Hands: hands[1:], Trump: AllSuits[i%4], Talon: hands[0]}
+
+		tricks, err := SolveTricks(DDPosition{Hands: pos.Hands, Trump: pos.Trump}, 0, false)
+		require.NoError(t, err)
+		assert.True(t, tricks >= 0 && tricks <= 10)
+
+		pos.Trump = SuitNoTrump
+		total := 0
+		for seat := 0; seat < 3; seat++ {
+			tricks, err := SolveTricks(pos, seat, true)
+			require.NoError(t, err)
+			total += tricks
+		}
+		assert.True(t, total >= 10)
+	}
+}
+
+func TestArchivedDealAnalysis(t *testing.T) {
+	strategies := []Strategy{&HeuristicStrategy{}, &HeuristicStrategy{}, &HeuristicStrategy{}}
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	require.NoError(t, room.Shuffle("evgsol"))
+
+	analysed := 0
+	for analysed < 5 {
+		index, kind := room.Decision()
+		if kind == DecisionMove {
+			pos, err := NewArchivedDeal(room).Position(len(room.Game.Plays))
+			require.NoError(t, err)
+			for seat, side := range room.Game.Players {
+				assert.ElementsMatch(t, room.Sides[side].Cards, pos.Hands[seat])
+			}
+			assert.Equal(t, index, room.Game.Players[(pos.Leader+len(pos.Trick))%3])
+		}
+		if kind == DecisionShuffle {
+			deal := NewArchivedDeal(room)
+			if len(deal.Plays) == 30 {
+				pos, err := deal.Position(len(deal.Plays))
+				require.NoError(t, err)
+				for _, hand := range pos.Hands {
+					assert.Empty(t, hand)
+				}
+
+				analysis, err := deal.Analyse()
+				require.NoError(t, err)
+				total := 0
+				for _, tricks := range analysis.Actual {
+					total += tricks
+				}
+				assert.Equal(t, 10, total)
+				if deal.Result.Contract != "" {
+					assert.Equal(t, 10, analysis.Optimal[0]+analysis.Optimal[1])
+				}
+
+				_, err = deal.Mistakes()
+				require.NoError(t, err)
+				analysed++
+			}
+		}
+
+		action := strategies[index].Decide(room.ViewFor(index))
+		require.NoError(t, room.Apply(room.Sides[index].Name, kind, action))
+	}
+}
+
+func TestMistakes(t *testing.T) {
+	deal := &ArchivedDeal{
+		Hands: [][]Card{
+			parseCards("AS", "KS", "7H"),
+			parseCards("QS", "8H", "AD"),
+			parseCards("JS", "9H", "KD"),
+		},
+		Plays: []CenterCardInfo{
+			{Card: Card{SuitHearts, "7"}, Player: "a"},
+			{Card: Card{SuitHearts, "8"}, Player: "b"},
+			{Card: Card{SuitHearts, "9"}, Player: "c"},
+			{Card: Card{SuitDiamonds, "K"}, Player: "c"},
+			{Card: Card{SuitSpades, "K"}, Player: "a"},
+			{Card: Card{SuitDiamonds, "A"}, Player: "b"},
+			{Card: Card{SuitSpades, "Q"}, Player: "b"},
+			{Card: Card{SuitSpades, "J"}, Player: "c"},
+			{Card: Card{SuitSpades, "A"}, Player: "a"},
+		},
+		Result: DealResult{
+			Players:  []string{"a", "b", "c"},
+			Tricks:   []int{1, 1, 1},
+			Contract: "6NT",
+			Declarer: 0,
+		},
+	}
+
+	report, err := deal.Mistakes()
+	require.NoError(t, err)
+	require.Len(t, report.Mistakes, 1)
+	assert.Equal(t, 0, report.Mistakes[0].Play)
+	assert.Equal(t, 1, report.Mistakes[0].Lost)
+	assert.ElementsMatch(t, parseCards("AS", "KS"), report.Mistakes[0].Best)
+	assert.Equal(t, map[string]int{"a": 1, "b": 0, "c": 0}, report.Lost)
+}
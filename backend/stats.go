@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const StatsCollectionName = "stats"
+
+// PlayerStats are the counters of the player over some deals. Declared are
+// the contracts by level, misere is counted separately.
+type PlayerStats struct {
+	Deals         int                    `json:"deals" bson:"deals"`
+	Declared      map[string]int         `json:"declared" bson:"declared"`
+	Made          int                    `json:"made" bson:"made"`
+	Defended      int                    `json:"defended" bson:"defended"`
+	Whisted       int                    `json:"whisted" bson:"whisted"`
+	WhistMade     int                    `json:"whistMade" bson:"whistMade"`
+	Misere        int                    `json:"misere" bson:"misere"`
+	MisereMade    int                    `json:"misereMade" bson:"misereMade"`
+	AllPass       int                    `json:"allPass" bson:"allPass"`
+	AllPassTricks int                    `json:"allPassTricks" bson:"allPassTricks"`
+	Balance       map[Convention]float64 `json:"balance" bson:"balance"`
+}
+
+func NewPlayerStats() PlayerStats {
+	return PlayerStats{
+		Declared: map[string]int{},
+		Balance:  map[Convention]float64{},
+	}
+}
+
+func (s *PlayerStats) Add(other PlayerStats) {
+	if s.Declared == nil {
+		s.Declared = map[string]int{}
+	}
+	if s.Balance == nil {
+		s.Balance = map[Convention]float64{}
+	}
+
+	s.Deals += other.Deals
+	for level, n := range other.Declared {
+		s.Declared[level] += n
+	}
+	s.Made += other.Made
+	s.Defended += other.Defended
+	s.Whisted += other.Whisted
+	s.WhistMade += other.WhistMade
+	s.Misere += other.Misere
+	s.MisereMade += other.MisereMade
+	s.AllPass += other.AllPass
+	s.AllPassTricks += other.AllPassTricks
+	for convention, b := range other.Balance {
+		s.Balance[convention] += b
+	}
+}
+
+// DealStats counts the archived deal for one of its players.
+func DealStats(deal *ArchivedDeal, playerName string) PlayerStats {
+	res := NewPlayerStats()
+	seat := deal.seat(playerName)
+	if seat == -1 {
+		return res
+	}
+	result := deal.Result
+
+	res.Deals = 1
+	if deal.Convention != "" && seat < len(deal.Balances) {
+		res.Balance[deal.Convention] = deal.Balances[seat]
+	}
+
+	switch {
+	case result.Contract == "":
+		res.AllPass = 1
+		res.AllPassTricks = result.Tricks[seat]
+	case result.Contract.IsMisere():
+		if seat == result.Declarer {
+			res.Misere = 1
+			if result.Tricks[seat] == 0 {
+				res.MisereMade = 1
+			}
+		}
+	case seat == result.Declarer:
+		level := result.Contract.Level()
+		res.Declared[strconv.Itoa(level)] = 1
+		if result.Tricks[seat] >= level {
+			res.Made = 1
+		}
+	default:
+		res.Defended = 1
+		if result.Whisted[seat] {
+			res.Whisted = 1
+			if whistMade(result, seat) {
+				res.WhistMade = 1
+			}
+		}
+	}
+
+	return res
+}
+
+// whistMade tells whether the whister escaped the penalty of the sheet.
+func whistMade(result DealResult, seat int) bool {
+	required := WhistRequirements[result.Contract.Level()]
+	defence, whisters := 0, 0
+	for i, t := range result.Tricks {
+		if i != result.Declarer {
+			defence += t
+		}
+		if result.Whisted[i] {
+			whisters++
+		}
+	}
+
+	if defence >= required {
+		return true
+	}
+
+	return whisters > 1 && result.Tricks[seat] >= (required+1)/2
+}
+
+// StatsReport adds the rates to the counters, the rates of nothing are zero.
+type StatsReport struct {
+	PlayerStats
+	DeclarerSuccess float64 `json:"declarerSuccess"`
+	WhistFrequency  float64 `json:"whistFrequency"`
+	WhistSuccess    float64 `json:"whistSuccess"`
+	AllPassAverage  float64 `json:"allPassAverage"`
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+
+	return float64(a) / float64(b)
+}
+
+func NewStatsReport(stats PlayerStats) StatsReport {
+	declared := 0
+	for _, n := range stats.Declared {
+		declared += n
+	}
+
+	return StatsReport{
+		PlayerStats:     stats,
+		DeclarerSuccess: ratio(stats.Made, declared),
+		WhistFrequency:  ratio(stats.Whisted, stats.Defended),
+		WhistSuccess:    ratio(stats.WhistMade, stats.Whisted),
+		AllPassAverage:  ratio(stats.AllPassTricks, stats.AllPass),
+	}
+}
+
+// StatsBucket keeps the stats of the player for the deals of a day with the
+// same opponents, so the reports sum up a few buckets instead of the deals.
+type StatsBucket struct {
+	ID        string      `bson:"_id"`
+	Player    string      `bson:"player"`
+	Day       time.Time   `bson:"day"`
+	Opponents []string    `bson:"opponents"`
+	Stats     PlayerStats `bson:"stats"`
+}
+
+func StatsDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// DealBuckets are the buckets of every player of the deal with the deal
+// counted. The bots and the puzzles are not counted.
+func DealBuckets(deal *ArchivedDeal) []StatsBucket {
+	if deal.Puzzle {
+		return nil
+	}
+
+	var res []StatsBucket
+	day := StatsDay(deal.Finished)
+	for _, name := range deal.Result.Players {
+		if strings.HasPrefix(name, BotNamePrefix) {
+			continue
+		}
+
+		var opponents []string
+		for _, other := range deal.Result.Players {
+			if other != name {
+				opponents = append(opponents, other)
+			}
+		}
+		sort.Strings(opponents)
+
+		res = append(res, StatsBucket{
+			ID:        strings.Join(append([]string{name, day.Format("2006-01-02")}, opponents...), "|"),
+			Player:    name,
+			Day:       day,
+			Opponents: opponents,
+			Stats:     DealStats(deal, name),
+		})
+	}
+
+	return res
+}
+
+// StatsFilter limits the report to the days from From to To including both,
+// and to the deals with all the given opponents. Zero times are open ends.
+type StatsFilter struct {
+	From      time.Time
+	To        time.Time
+	Opponents []string
+}
+
+type StatsDAO struct {
+	collection *mgo.Collection
+}
+
+func NewStatsDAO(session *mgo.Session) *StatsDAO {
+	return &StatsDAO{
+		collection: session.DB(RoomDatabaseName).C(StatsCollectionName),
+	}
+}
+
+// Add counts the bucket's stats in the stored bucket with the same ID.
+func (d *StatsDAO) Add(ctx context.Context, bucket StatsBucket) error {
+	_, err := d.collection.UpsertId(bucket.ID, bson.M{
+		"$setOnInsert": bson.M{
+			"player":    bucket.Player,
+			"day":       bucket.Day,
+			"opponents": bucket.Opponents,
+		},
+		"$inc": statsIncrements(bucket.Stats),
+	})
+	return err
+}
+
+func statsIncrements(stats PlayerStats) bson.M {
+	res := bson.M{
+		"stats.deals":         stats.Deals,
+		"stats.made":          stats.Made,
+		"stats.defended":      stats.Defended,
+		"stats.whisted":       stats.Whisted,
+		"stats.whistMade":     stats.WhistMade,
+		"stats.misere":        stats.Misere,
+		"stats.misereMade":    stats.MisereMade,
+		"stats.allPass":       stats.AllPass,
+		"stats.allPassTricks": stats.AllPassTricks,
+	}
+	for level, n := range stats.Declared {
+		res["stats.declared."+level] = n
+	}
+	for convention, b := range stats.Balance {
+		res["stats.balance."+string(convention)] = b
+	}
+
+	return res
+}
+
+func (d *StatsDAO) Find(ctx context.Context, playerName string, filter StatsFilter) ([]StatsBucket, error) {
+	query := bson.M{"player": playerName}
+	day := bson.M{}
+	if !filter.From.IsZero() {
+		day["$gte"] = StatsDay(filter.From)
+	}
+	if !filter.To.IsZero() {
+		day["$lte"] = StatsDay(filter.To)
+	}
+	if len(day) > 0 {
+		query["day"] = day
+	}
+	if len(filter.Opponents) > 0 {
+		query["opponents"] = bson.M{"$all": filter.Opponents}
+	}
+
+	var result []StatsBucket
+	if err := d.collection.Find(query).All(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (d *StatsDAO) RemoveAll(ctx context.Context) error {
+	_, err := d.collection.RemoveAll(bson.M{})
+	return err
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDealStats(t *testing.T) {
+	deal := &ArchivedDeal{
+		Finished:   time.Date(2021, 3, 8, 23, 30, 0, 0, time.UTC),
+		Convention: ConventionSochi,
+		Balances:   []float64{20, -8, -12},
+		Result: DealResult{
+			Players:  []string{"evgsol", "solarka", "psmirnov"},
+			Tricks:   []int{9, 1, 0},
+			Contract: "7H",
+			Declarer: 0,
+			Whisted:  []bool{false, true, true},
+		},
+	}
+
+	declarer := DealStats(deal, "evgsol")
+	assert.Equal(t, map[string]int{"7": 1}, declarer.Declared)
+	assert.Equal(t, 1, declarer.Made)
+	assert.Equal(t, map[Convention]float64{ConventionSochi: 20}, declarer.Balance)
+
+	whister := DealStats(deal, "solarka")
+	assert.Equal(t, 1, whister.Whisted)
+	assert.Equal(t, 1, whister.WhistMade)
+	assert.Equal(t, 0, DealStats(deal, "psmirnov").WhistMade)
+	assert.Equal(t, 0, DealStats(deal, "stranger").Deals)
+
+	buckets := DealBuckets(deal)
+	assert.Len(t, buckets, 3)
+	assert.Equal(t, "solarka|2021-03-08|evgsol|psmirnov", buckets[1].ID)
+
+	deal.Result.Players[2] = BotNamePrefix + "1"
+	buckets = DealBuckets(deal)
+	assert.Len(t, buckets, 2)
+	assert.Equal(t, []string{"bot-1", "evgsol"}, buckets[1].Opponents)
+	deal.Puzzle = true
+	assert.Empty(t, DealBuckets(deal))
+
+	allPass := &ArchivedDeal{Result: DealResult{
+		Players:  []string{"evgsol", "solarka", "psmirnov"},
+		Tricks:   []int{2, 5, 3},
+		Declarer: -1,
+		Whisted:  []bool{false, false, false},
+	}}
+	misere := &ArchivedDeal{Result: DealResult{
+		Players:  []string{"evgsol", "solarka", "psmirnov"},
+		Tricks:   []int{0, 4, 6},
+		Contract: BidMisere,
+		Declarer: 0,
+		Whisted:  []bool{false, false, false},
+	}}
+
+	stats := NewPlayerStats()
+	for _, d := range []*ArchivedDeal{deal, allPass, misere} {
+		stats.Add(DealStats(d, "evgsol"))
+	}
+	report := NewStatsReport(stats)
+	assert.Equal(t, 3, report.Deals)
+	assert.Equal(t, 1, report.MisereMade)
+	assert.Equal(t, 1.0, report.DeclarerSuccess)
+	assert.Equal(t, 2.0, report.AllPassAverage)
+	assert.Equal(t, 0.0, report.WhistSuccess)
+}
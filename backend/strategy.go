@@ -0,0 +1,433 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Strategy decides for a bot. It is called only when the bot has to act.
+type Strategy interface {
+	Decide(view BotView) Action
+}
+
+// Strategies create bots by name, the seed is used by the randomized ones.
+var Strategies = map[string]func(seed int64) Strategy{
+	"heuristic": func(int64) Strategy {
+		return &HeuristicStrategy{}
+	},
+	"random": func(seed int64) Strategy {
+		return NewRandomStrategy(seed)
+	},
+	"solver": func(int64) Strategy {
+		return &SolverStrategy{}
+	},
+}
+
+type RandomStrategy struct {
+	rand *rand.Rand
+}
+
+func NewRandomStrategy(seed int64) *RandomStrategy {
+	return &RandomStrategy{
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (s *RandomStrategy) Decide(view BotView) Action {
+	switch view.Kind {
+	case DecisionBid:
+		if len(view.LegalBids) > 1 && s.rand.Intn(4) == 0 {
+			return Action{Bid: view.LegalBids[1]}
+		}
+		return Action{Bid: BidPass}
+	case DecisionDeclare:
+		return Action{Bid: view.LegalBids[0]}
+	case DecisionDrop:
+		indexes := s.rand.Perm(len(view.Hand))[:2]
+		return Action{Indexes: indexes}
+	case DecisionWhist:
+		return Action{Whist: s.rand.Intn(2) == 0}
+	case DecisionMove:
+		return Action{Index: view.LegalMoves[s.rand.Intn(len(view.LegalMoves))]}
+	}
+
+	return Action{}
+}
+
+// HeuristicStrategy counts likely tricks of the hand for the auction and
+// plays the cheapest card which does the job.
+type HeuristicStrategy struct{}
+
+func (s *HeuristicStrategy) Decide(view BotView) Action {
+	switch view.Kind {
+	case DecisionBid:
+		return Action{Bid: s.bid(view)}
+	case DecisionDeclare:
+		return Action{Bid: s.declare(view)}
+	case DecisionDrop:
+		return Action{Indexes: s.drop(view)}
+	case DecisionWhist:
+		return Action{Whist: s.whist(view)}
+	case DecisionMove:
+		return Action{Index: s.move(view)}
+	}
+
+	return Action{}
+}
+
+// SolverStrategy plays the cards with the double dummy solver when it sees
+// all of them, as in the puzzles, and like HeuristicStrategy otherwise.
+type SolverStrategy struct {
+	HeuristicStrategy
+}
+
+func (s *SolverStrategy) Decide(view BotView) Action {
+	if view.Kind == DecisionMove {
+		if index, ok := view.Room.BestMove(view.Side, view.LegalMoves); ok {
+			return Action{Index: index}
+		}
+	}
+
+	return s.HeuristicStrategy.Decide(view)
+}
+
+const BuypackBonus = 0.7
+
+func (s *HeuristicStrategy) bid(view BotView) Bid {
+	level, trump := BestContract(view.Hand, BuypackBonus)
+	canMisere := containsBid(view.LegalBids, BidMisere)
+	if canMisere && level < 6 && MisereRisk(view.Hand) <= 1 {
+		return BidMisere
+	}
+
+	for _, b := range view.LegalBids {
+		if b.IsPass() || b.IsMisere() {
+			continue
+		}
+		if level >= 6 && b.order() <= NewBid(level, trump).order() {
+			return b
+		}
+		break
+	}
+
+	return BidPass
+}
+
+func (s *HeuristicStrategy) declare(view BotView) Bid {
+	if len(view.LegalBids) == 1 {
+		return view.LegalBids[0]
+	}
+
+	level, trump := BestContract(view.Hand, 0)
+	best := view.LegalBids[0]
+	for _, b := range view.LegalBids {
+		if b.Trump() == trump && b.Level() <= level && b.order() > best.order() {
+			best = b
+		}
+	}
+
+	return best
+}
+
+func (s *HeuristicStrategy) drop(view BotView) []int {
+	misere := view.Room.Game != nil && view.Room.Game.HighestBid().IsMisere()
+
+	best := []int{0, 1}
+	bestScore := -1000.0
+	for i := 0; i < len(view.Hand); i++ {
+		for j := i + 1; j < len(view.Hand); j++ {
+			var rest []Card
+			for k, c := range view.Hand {
+				if k != i && k != j {
+					rest = append(rest, c)
+				}
+			}
+
+			var score float64
+			if misere {
+				score = -float64(MisereRisk(rest))*10 + float64(view.Hand[i].rankNumber()+view.Hand[j].rankNumber())/10
+			} else {
+				_, trump := BestContract(rest, 0)
+				score = EstimateTricks(rest, trump)
+			}
+
+			if score > bestScore {
+				bestScore = score
+				best = []int{i, j}
+			}
+		}
+	}
+
+	return best
+}
+
+func (s *HeuristicStrategy) whist(view BotView) bool {
+	game := view.Room.Game
+	required := map[int]float64{6: 4, 7: 2, 8: 1, 9: 1, 10: 1}[game.Contract.Level()]
+
+	share := 0.5
+	for _, w := range game.Whists {
+		if !w.Whist {
+			share = 0.75
+		}
+	}
+
+	return DefenceTricks(view.Hand, game.Contract.Trump()) >= required*share
+}
+
+func (s *HeuristicStrategy) move(view BotView) int {
+	legal := view.LegalMoves
+	if len(legal) == 1 {
+		return legal[0]
+	}
+
+	room := view.Room
+	game := room.Game
+	trump := room.Trump()
+	hand := view.Hand
+
+	played := 0
+	for _, c := range room.Center {
+		if room.isPlayerCard(c) {
+			played++
+		}
+	}
+	last := played == len(game.Players)-1
+
+	var winners, losers []int
+	winner := -1
+	if played > 0 {
+		winner = TrickWinner(room.Center, room.isPlayerCard, trump)
+		for _, i := range legal {
+			center := append(append([]CenterCardInfo{}, room.Center...), CenterCardInfo{
+				Card:   hand[i],
+				Player: room.Sides[view.Side].Name,
+			})
+			if TrickWinner(center, room.isPlayerCard, trump) == len(room.Center) {
+				winners = append(winners, i)
+			} else {
+				losers = append(losers, i)
+			}
+		}
+	}
+
+	lowest := func(indexes []int) int {
+		return extremeCard(hand, indexes, trump, false)
+	}
+	highest := func(indexes []int) int {
+		return extremeCard(hand, indexes, trump, true)
+	}
+
+	// All pass and misere declarer try to take nothing.
+	if room.Status == RoomStatusAllPass || (game.Contract.IsMisere() && view.Side == game.Declarer) {
+		if played == 0 {
+			return lowest(legal)
+		}
+		if len(losers) > 0 {
+			return highest(losers)
+		}
+		if last {
+			return highest(legal)
+		}
+		return lowest(legal)
+	}
+
+	if game.Contract.IsMisere() {
+		return lowest(legal)
+	}
+
+	if played == 0 {
+		var aces []int
+		for _, i := range legal {
+			if hand[i].Rank == "A" {
+				aces = append(aces, i)
+			}
+		}
+		if view.Side == game.Declarer && trump != SuitNoTrump {
+			for _, i := range aces {
+				if hand[i].Suit == trump {
+					return i
+				}
+			}
+		}
+		if len(aces) > 0 {
+			return aces[0]
+		}
+		return lowest(longestSuit(hand, legal, trump))
+	}
+
+	winnerSide := room.PlayerSideIndex(room.Center[winner].Player)
+	partnerWins := view.Side != game.Declarer && winnerSide != game.Declarer
+	if partnerWins && (last || room.Center[winner].Card.rankNumber() >= 6) {
+		return lowest(legal)
+	}
+
+	if len(winners) > 0 {
+		return lowest(winners)
+	}
+
+	return lowest(legal)
+}
+
+func extremeCard(hand []Card, indexes []int, trump Suit, highest bool) int {
+	res := indexes[0]
+	for _, i := range indexes[1:] {
+		c, best := hand[i], hand[res]
+		// Trumps are kept while there is a choice.
+		if (c.Suit == trump) != (best.Suit == trump) {
+			if best.Suit == trump {
+				res = i
+			}
+			continue
+		}
+		if highest && c.rankNumber() > best.rankNumber() {
+			res = i
+		}
+		if !highest && c.rankNumber() < best.rankNumber() {
+			res = i
+		}
+	}
+
+	return res
+}
+
+func longestSuit(hand []Card, indexes []int, trump Suit) []int {
+	bySuit := map[Suit][]int{}
+	for _, i := range indexes {
+		bySuit[hand[i].Suit] = append(bySuit[hand[i].Suit], i)
+	}
+
+	var res []int
+	for suit, cards := range bySuit {
+		if suit == trump && len(bySuit) > 1 {
+			continue
+		}
+		if len(cards) > len(res) || (len(cards) == len(res) && hand[cards[0]].Suit < hand[res[0]].Suit) {
+			res = cards
+		}
+	}
+
+	return res
+}
+
+// suitRanks returns rank numbers of the suit cards in descending order.
+func suitRanks(hand []Card, suit Suit) []int {
+	var res []int
+	for _, c := range hand {
+		if c.Suit == suit {
+			res = append(res, c.rankNumber())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(res)))
+
+	return res
+}
+
+// TopTricks estimates the tricks taken by the high cards of a suit: a card
+// with n higher cards outside the hand wins if the suit is long enough to
+// wait for them, with the probability shrinking with n.
+func TopTricks(ranks []int) float64 {
+	res := 0.0
+	next := len(AllRanks) - 1
+	missing := 0
+	for i, r := range ranks {
+		missing += next - r
+		next = r - 1
+		if len(ranks)-i <= missing {
+			continue
+		}
+		res += 1 / float64(1+missing*missing)
+	}
+
+	return res
+}
+
+func TrumpTricks(ranks []int) float64 {
+	top := TopTricks(ranks)
+	length := top + float64(2*len(ranks)-8)
+	if length > float64(len(ranks)) {
+		length = float64(len(ranks))
+	}
+	if length > top {
+		return length
+	}
+
+	return top
+}
+
+// EstimateTricks estimates the tricks of the declarer with the trump.
+func EstimateTricks(hand []Card, trump Suit) float64 {
+	res := 0.0
+	for _, suit := range AllSuits {
+		ranks := suitRanks(hand, suit)
+		if suit == trump {
+			res += TrumpTricks(ranks)
+			continue
+		}
+
+		top := TopTricks(ranks)
+		res += top
+		if trump == SuitNoTrump && top >= 2 && len(ranks) > 4 {
+			res += float64(len(ranks) - 4)
+		}
+	}
+
+	return res
+}
+
+func DefenceTricks(hand []Card, trump Suit) float64 {
+	res := 0.0
+	for _, suit := range AllSuits {
+		ranks := suitRanks(hand, suit)
+		if suit == trump {
+			res += TopTricks(ranks)
+			continue
+		}
+		// Long side suits are ruffed after the first round or two.
+		top := TopTricks(ranks)
+		if len(ranks) > 4 && top > 1 {
+			top = 1
+		}
+		res += top
+	}
+
+	return res
+}
+
+// BestContract returns the highest level the hand may expect and its best
+// trump, bonus is added for the buypack which is not taken yet.
+func BestContract(hand []Card, bonus float64) (int, Suit) {
+	bestLevel, bestTrump := 0, SuitNoTrump
+	bestTricks := -1.0
+	for _, trump := range BidSuits {
+		tricks := EstimateTricks(hand, trump) + bonus
+		if tricks > bestTricks {
+			bestTricks, bestTrump = tricks, trump
+		}
+	}
+
+	bestLevel = int(bestTricks + 0.3)
+	if bestLevel > 10 {
+		bestLevel = 10
+	}
+
+	return bestLevel, bestTrump
+}
+
+// MisereRisk counts cards which may be forced to take a trick: the i-th
+// lowest card of a suit is safe while it is not higher than the i-th card
+// of the 7-9-J-K ladder.
+func MisereRisk(hand []Card) int {
+	res := 0
+	for _, suit := range AllSuits {
+		ranks := suitRanks(hand, suit)
+		sort.Ints(ranks)
+		for i, r := range ranks {
+			if r > 2*i {
+				res++
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,204 @@
+package main
+
+import "sort"
+
+const (
+	MaxRounds = 12
+	// ByePoints are the average place points of a table of three.
+	ByePoints = 1.0
+)
+
+type SwissRound struct {
+	Number int               `json:"number" bson:"number"`
+	Tables []TournamentTable `json:"tables" bson:"tables"`
+	Byes   []string          `json:"byes,omitempty" bson:"byes,omitempty"`
+}
+
+// PlacePoints give a point for every player of the table with the worse
+// result and a half for a tie.
+func PlacePoints(results []PulkaResult) []float64 {
+	res := make([]float64, len(results))
+	for i := range results {
+		for j := range results {
+			if i == j {
+				continue
+			}
+			if c := results[i].compare(results[j]); c > 0 {
+				res[i]++
+			} else if c == 0 {
+				res[i] += 0.5
+			}
+		}
+	}
+
+	return res
+}
+
+// settle closes the table with the results, the no-shows lose to all the
+// present players.
+func (t *TournamentTable) settle(results []PulkaResult) {
+	t.Balances = make([]float64, len(results))
+	for i, name := range t.Players {
+		if containsName(t.NoShows, name) {
+			results[i].Forfeit = true
+		}
+		t.Balances[i] = results[i].Balance
+	}
+
+	t.Points = PlacePoints(results)
+	t.Finished = true
+}
+
+type SwissStanding struct {
+	Player   string  `json:"player"`
+	Points   float64 `json:"points"`
+	Buchholz float64 `json:"buchholz"`
+	Balance  float64 `json:"balance"`
+	Rounds   int     `json:"rounds"`
+}
+
+// SwissStandings sum up the finished tables and byes of the players. The
+// ties are broken by Buchholz, the sum of the points of the opponents met,
+// then by the total balance.
+func SwissStandings(players []string, rounds []SwissRound) []SwissStanding {
+	byName := map[string]*SwissStanding{}
+	for _, name := range players {
+		byName[name] = &SwissStanding{Player: name}
+	}
+
+	opponents := map[string][]string{}
+	for _, round := range rounds {
+		for _, name := range round.Byes {
+			if s := byName[name]; s != nil {
+				s.Points += ByePoints
+				s.Rounds++
+			}
+		}
+		for _, table := range round.Tables {
+			if !table.Finished {
+				continue
+			}
+			for i, name := range table.Players {
+				s := byName[name]
+				if s == nil {
+					continue
+				}
+				s.Points += table.Points[i]
+				if !containsName(table.NoShows, name) {
+					s.Balance += table.Balances[i]
+				}
+				s.Rounds++
+				for _, other := range table.Players {
+					if other != name {
+						opponents[name] = append(opponents[name], other)
+					}
+				}
+			}
+		}
+	}
+
+	res := []SwissStanding{}
+	for _, name := range players {
+		s := byName[name]
+		for _, other := range opponents[name] {
+			if o := byName[other]; o != nil {
+				s.Buchholz += o.Points
+			}
+		}
+		res = append(res, *s)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.Buchholz != b.Buchholz {
+			return a.Buchholz > b.Buchholz
+		}
+		if a.Balance != b.Balance {
+			return a.Balance > b.Balance
+		}
+		return a.Player < b.Player
+	})
+
+	return res
+}
+
+// SwissPairing seats the players in the order of the standings at the
+// tables of three, avoiding the opponents met before where it can. The
+// players left over get byes, the lowest ones without a bye first.
+func SwissPairing(standings []SwissStanding, rounds []SwissRound) ([][]string, []string) {
+	met := map[string]map[string]bool{}
+	hadBye := map[string]bool{}
+	for _, round := range rounds {
+		for _, name := range round.Byes {
+			hadBye[name] = true
+		}
+		for _, table := range round.Tables {
+			for _, a := range table.Players {
+				if met[a] == nil {
+					met[a] = map[string]bool{}
+				}
+				for _, b := range table.Players {
+					met[a][b] = true
+				}
+			}
+		}
+	}
+
+	var rest []string
+	for _, s := range standings {
+		rest = append(rest, s.Player)
+	}
+
+	var byes []string
+	for i := len(rest) - 1; i >= 0 && len(byes) < len(standings)%TournamentTableSize; i-- {
+		if !hadBye[rest[i]] {
+			byes = append(byes, rest[i])
+		}
+	}
+	for i := len(rest) - 1; i >= 0 && len(byes) < len(standings)%TournamentTableSize; i-- {
+		if !containsName(byes, rest[i]) {
+			byes = append(byes, rest[i])
+		}
+	}
+	rest = removeNames(rest, byes)
+
+	var tables [][]string
+	for len(rest) > 0 {
+		table := []string{rest[0]}
+		rest = rest[1:]
+		for len(table) < TournamentTableSize {
+			pick := 0
+			for i, candidate := range rest {
+				fresh := true
+				for _, seated := range table {
+					if met[seated][candidate] {
+						fresh = false
+					}
+				}
+				if fresh {
+					pick = i
+					break
+				}
+			}
+			table = append(table, rest[pick])
+			rest = append(rest[:pick:pick], rest[pick+1:]...)
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, byes
+}
+
+func removeNames(names, removed []string) []string {
+	var res []string
+	for _, name := range names {
+		if !containsName(removed, name) {
+			res = append(res, name)
+		}
+	}
+
+	return res
+}
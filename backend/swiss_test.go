@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwissStandings(t *testing.T) {
+	assert.Equal(t, []float64{2, 0.5, 0.5}, PlacePoints(balanceResults(30, -15, -15)))
+
+	table := TournamentTable{Players: []string{"a", "b", "c"}, NoShows: []string{"c"}}
+	table.settle(balanceResults(-10, 0, 10))
+	assert.Equal(t, []float64{1, 2, 0}, table.Points)
+	assert.Equal(t, []float64{-10, 0, 10}, table.Balances)
+	assert.True(t, table.Finished)
+
+	rounds := []SwissRound{{
+		Number: 1,
+		Tables: []TournamentTable{
+			{Players: []string{"a", "b", "c"}, Balances: []float64{20, -5, -15}, Points: []float64{2, 1, 0}, Finished: true},
+			{Players: []string{"d", "e", "f"}, Balances: []float64{40, -10, -30}, Points: []float64{2, 1, 0}, Finished: true},
+			{Players: []string{"g", "h", "i"}},
+		},
+		Byes: []string{"j"},
+	}}
+	standings := SwissStandings([]string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}, rounds)
+	var names []string
+	for _, s := range standings {
+		names = append(names, s.Player)
+	}
+	assert.Equal(t, []string{"d", "a", "b", "e", "j", "c", "f", "g", "h", "i"}, names)
+	assert.Equal(t, 1.0, standings[0].Buchholz)
+	assert.Equal(t, 0, standings[7].Rounds)
+
+	tables, byes := SwissPairing(standings, rounds)
+	assert.Equal(t, []string{"i"}, byes)
+	require.Len(t, tables, 3)
+	assert.Equal(t, []string{"d", "a", "j"}, tables[0])
+	for _, table := range tables {
+		assert.Len(t, table, 3)
+	}
+}
+
+func TestTournamentViewJSON(t *testing.T) {
+	table := TournamentTable{Players: []string{"a", "b", "c"}, NoShows: []string{"c"}}
+	table.settle(make([]PulkaResult, 3))
+	rounds := []SwissRound{{Number: 1, Tables: []TournamentTable{table}}}
+
+	score := NewScoreSheet([]string{"a", "b", "c"}, ConventionSochi, 10)
+	score.Forfeit = "b"
+	forfeit := TournamentTable{Players: []string{"a", "b", "c"}}
+	forfeit.settle(score.Results())
+	rounds = append(rounds, SwissRound{Number: 2, Tables: []TournamentTable{forfeit}})
+
+	view := TournamentView{
+		Tournament: &Tournament{Format: FormatSwiss, Players: []string{"a", "b", "c"}, Rounds: rounds},
+		Standings:  SwissStandings([]string{"a", "b", "c"}, rounds),
+	}
+	data, err := json.Marshal(view)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"standings"`)
+}
+
+func TestSinglePulka(t *testing.T) {
+	room := newTestRoom("evgsol", "solarka", "psmirnov")
+	room.Settings = &RoomSettings{MaxPlayers: 3, SinglePulka: true}
+	require.NoError(t, room.Shuffle("evgsol"))
+
+	room.Game.Finished = true
+	room.Score.Finished = true
+	assert.Error(t, room.Shuffle("evgsol"))
+}
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const TournamentCollectionName = "tournaments"
+
+const (
+	MaxBoards           = 60
+	TournamentTableSize = 3
+)
+
+const (
+	TournamentCreated  = "created"
+	TournamentRunning  = "running"
+	TournamentFinished = "finished"
+)
+
+// The duplicate tournaments play the same boards at all the tables, the
+// Swiss ones play a pulka a round at the tables seated by the standings.
+const (
+	FormatDuplicate = "duplicate"
+	FormatSwiss     = "swiss"
+)
+
+type TournamentID = RoomID
+
+// TournamentTable keeps the result of the Swiss table once it is finished,
+// the slices are aligned with the players.
+type TournamentTable struct {
+	RoomID   RoomID    `json:"roomId" bson:"roomId"`
+	Players  []string  `json:"players" bson:"players"`
+	Balances []float64 `json:"balances,omitempty" bson:"balances,omitempty"`
+	Points   []float64 `json:"points,omitempty" bson:"points,omitempty"`
+	NoShows  []string  `json:"noShows,omitempty" bson:"noShows,omitempty"`
+	Finished bool      `json:"finished,omitempty" bson:"finished,omitempty"`
+}
+
+// Tournament is duplicate or Swiss. The boards of the duplicate one are
+// generated when it starts, the Swiss one seats the tables round by round.
+type Tournament struct {
+	ID          TournamentID      `json:"id" bson:"_id"`
+	Name        string            `json:"name" bson:"name"`
+	Host        string            `json:"host" bson:"host"`
+	Format      string            `json:"format" bson:"format"`
+	Convention  Convention        `json:"convention" bson:"convention"`
+	BoardsCount int               `json:"boards,omitempty" bson:"boardsCount,omitempty"`
+	Boards      []Board           `json:"-" bson:"boards,omitempty"`
+	RoundsCount int               `json:"roundsCount,omitempty" bson:"roundsCount,omitempty"`
+	Rounds      []SwissRound      `json:"rounds,omitempty" bson:"rounds,omitempty"`
+	Players     []string          `json:"players" bson:"players"`
+	Withdrawn   []string          `json:"withdrawn,omitempty" bson:"withdrawn,omitempty"`
+	Tables      []TournamentTable `json:"tables,omitempty" bson:"tables,omitempty"`
+	Status      string            `json:"status" bson:"status"`
+	Created     time.Time         `json:"created" bson:"created"`
+	// Version guards the updates against the concurrent ones.
+	Version int `json:"-" bson:"version"`
+}
+
+// ActivePlayers are the players not withdrawn after a no-show.
+func (t *Tournament) ActivePlayers() []string {
+	return removeNames(t.Players, t.Withdrawn)
+}
+
+func (t *Tournament) currentRound() *SwissRound {
+	if len(t.Rounds) == 0 {
+		return nil
+	}
+
+	return &t.Rounds[len(t.Rounds)-1]
+}
+
+// TableSeating splits the players into the tables in the given order, the
+// players then move through the seats of their table board by board, see
+// SeatMovement.
+func TableSeating(players []string) ([][]string, error) {
+	if len(players) < 2*TournamentTableSize || len(players)%TournamentTableSize != 0 {
+		return nil, errors.New("wrong players count")
+	}
+
+	var res [][]string
+	for t := 0; t < len(players); t += TournamentTableSize {
+		res = append(res, append([]string{}, players[t:t+TournamentTableSize]...))
+	}
+
+	return res, nil
+}
+
+type TournamentDAO struct {
+	collection *mgo.Collection
+}
+
+func NewTournamentDAO(session *mgo.Session) *TournamentDAO {
+	return &TournamentDAO{
+		collection: session.DB(RoomDatabaseName).C(TournamentCollectionName),
+	}
+}
+
+func (d *TournamentDAO) Insert(ctx context.Context, tournament *Tournament) error {
+	return d.collection.Insert(tournament)
+}
+
+func (d *TournamentDAO) FindOneByID(ctx context.Context, id TournamentID) (*Tournament, error) {
+	var result Tournament
+	if err := d.collection.FindId(id).One(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (d *TournamentDAO) FindRunning(ctx context.Context, format string) ([]Tournament, error) {
+	var result []Tournament
+	if err := d.collection.Find(bson.M{"status": TournamentRunning, "format": format}).All(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+var errTournamentChanged = errors.New("tournament has changed")
+
+// Update writes the tournament unless it has been updated since it was read.
+func (d *TournamentDAO) Update(ctx context.Context, tournament *Tournament) error {
+	tournament.Version++
+	err := d.collection.Update(bson.M{
+		"_id":     tournament.ID,
+		"version": tournament.Version - 1,
+	}, tournament)
+	if errors.Is(err, mgo.ErrNotFound) {
+		return errTournamentChanged
+	}
+
+	return err
+}
+
+func (d *TournamentDAO) RemoveAll(ctx context.Context) error {
+	_, err := d.collection.RemoveAll(bson.M{})
+	return err
+}
+
+type TournamentManager struct {
+	dao         *TournamentDAO
+	roomManager *RoomManager
+}
+
+func NewTournamentManager(roomManager *RoomManager) *TournamentManager {
+	return &TournamentManager{
+		dao:         NewTournamentDAO(roomManager.dao.collection.Database.Session),
+		roomManager: roomManager,
+	}
+}
+
+// Create registers the host as the first player of a duplicate tournament.
+func (m *TournamentManager) Create(ctx context.Context, host, name string, convention Convention, boards int) (TournamentID, error) {
+	if boards <= 0 || boards > MaxBoards {
+		return TournamentID{}, errors.New("wrong boards count")
+	}
+
+	return m.insert(ctx, &Tournament{
+		Name:        name,
+		Host:        host,
+		Format:      FormatDuplicate,
+		Convention:  convention,
+		BoardsCount: boards,
+	})
+}
+
+// CreateSwiss registers the host as the first player of a Swiss tournament.
+func (m *TournamentManager) CreateSwiss(ctx context.Context, host, name string, convention Convention, rounds int) (TournamentID, error) {
+	if rounds <= 0 || rounds > MaxRounds {
+		return TournamentID{}, errors.New("wrong rounds count")
+	}
+
+	return m.insert(ctx, &Tournament{
+		Name:        name,
+		Host:        host,
+		Format:      FormatSwiss,
+		Convention:  convention,
+		RoundsCount: rounds,
+	})
+}
+
+func (m *TournamentManager) insert(ctx context.Context, tournament *Tournament) (TournamentID, error) {
+	if tournament.Name == "" {
+		return TournamentID{}, errors.New("empty name")
+	}
+	if !tournament.Convention.Valid() {
+		return TournamentID{}, errors.New("wrong convention")
+	}
+
+	tournament.ID = NewRoomID()
+	tournament.Players = []string{tournament.Host}
+	tournament.Status = TournamentCreated
+	tournament.Created = time.Now()
+
+	return tournament.ID, m.dao.Insert(ctx, tournament)
+}
+
+func (m *TournamentManager) Register(ctx context.Context, id TournamentID, playerName string) error {
+	tournament, err := m.dao.FindOneByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if tournament.Status != TournamentCreated {
+		return errors.New("tournament has started")
+	}
+
+	if containsName(tournament.Players, playerName) {
+		return errors.New("player is already registered")
+	}
+
+	tournament.Players = append(tournament.Players, playerName)
+	return m.dao.Update(ctx, tournament)
+}
+
+func (m *TournamentManager) getHosted(ctx context.Context, id TournamentID, playerName string) (*Tournament, error) {
+	tournament, err := m.dao.FindOneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if tournament.Host != playerName {
+		return nil, errors.New("player is not host")
+	}
+
+	return tournament, nil
+}
+
+// Start seats the players at the tables of the first round, the tables are
+// removed if the tournament has changed meanwhile.
+func (m *TournamentManager) Start(ctx context.Context, id TournamentID, playerName string) error {
+	tournament, err := m.getHosted(ctx, id, playerName)
+	if err != nil {
+		return err
+	}
+
+	if tournament.Status != TournamentCreated {
+		return errors.New("tournament has started")
+	}
+
+	if err := m.checkFree(ctx, tournament.Players); err != nil {
+		return err
+	}
+
+	if tournament.Format == FormatSwiss {
+		if len(tournament.Players) < TournamentTableSize {
+			return errors.New("wrong players count")
+		}
+		if err := m.startRound(ctx, tournament); err != nil {
+			return err
+		}
+	} else if err := m.startDuplicate(ctx, tournament); err != nil {
+		return err
+	}
+
+	tournament.Status = TournamentRunning
+	if err := m.dao.Update(ctx, tournament); err != nil {
+		tables := tournament.Tables
+		if round := tournament.currentRound(); round != nil {
+			tables = round.Tables
+		}
+		return m.removeTables(ctx, tables, err)
+	}
+
+	return nil
+}
+
+func (m *TournamentManager) checkFree(ctx context.Context, players []string) error {
+	for _, name := range players {
+		room, err := m.roomManager.GetOneForPlayer(ctx, name)
+		if err != nil {
+			return err
+		}
+		if room != nil {
+			return errors.New("player is already in room")
+		}
+	}
+
+	return nil
+}
+
+// createTable opens the room of a table with the settings fixed by the
+// tournament, the duplicate table is nil for the Swiss tournaments.
+func (m *TournamentManager) createTable(ctx context.Context, tournament *Tournament, players []string, duplicate *DuplicateTable) (RoomID, error) {
+	settings, err := NewRoomSettings(true, "", TournamentTableSize)
+	if err != nil {
+		return RoomID{}, err
+	}
+	settings.Convention = tournament.Convention
+	settings.SinglePulka = tournament.Format == FormatSwiss
+
+	roomID, err := m.roomManager.CreateReadyRoom(ctx, players, settings)
+	if err != nil {
+		return RoomID{}, err
+	}
+
+	if err := m.roomManager.SetTournamentTable(ctx, roomID, duplicate); err != nil {
+		return RoomID{}, m.removeTables(ctx, []TournamentTable{{RoomID: roomID}}, err)
+	}
+
+	return roomID, nil
+}
+
+// startDuplicate generates the boards and seats the players at the tables.
+// The tables seated before a failure are removed.
+func (m *TournamentManager) startDuplicate(ctx context.Context, tournament *Tournament) error {
+	seating, err := TableSeating(tournament.Players)
+	if err != nil {
+		return err
+	}
+
+	if tournament.Boards, err = NewBoards(tournament.BoardsCount); err != nil {
+		return err
+	}
+
+	var tables []TournamentTable
+	for t, players := range seating {
+		roomID, err := m.createTable(ctx, tournament, players, &DuplicateTable{
+			TournamentID: tournament.ID,
+			Table:        t,
+			Players:      players,
+			Boards:       tournament.Boards,
+		})
+		if err != nil {
+			return m.removeTables(ctx, tables, err)
+		}
+		tables = append(tables, TournamentTable{RoomID: roomID, Players: players})
+	}
+	tournament.Tables = tables
+
+	return nil
+}
+
+// removeTables removes the rooms of the tables after the failure to seat the
+// tournament and returns the failure.
+func (m *TournamentManager) removeTables(ctx context.Context, tables []TournamentTable, failure error) error {
+	for _, table := range tables {
+		err := m.roomManager.dao.Remove(ctx, table.RoomID)
+		if err != nil && !errors.Is(err, mgo.ErrNotFound) {
+			return fmt.Errorf("%w, the tables are not removed: %v", failure, err)
+		}
+	}
+
+	return failure
+}
+
+// startRound seats the active players of the Swiss tournament by the
+// standings.
+func (m *TournamentManager) startRound(ctx context.Context, tournament *Tournament) error {
+	if err := m.checkFree(ctx, tournament.ActivePlayers()); err != nil {
+		return err
+	}
+
+	standings := SwissStandings(tournament.ActivePlayers(), tournament.Rounds)
+	seating, byes := SwissPairing(standings, tournament.Rounds)
+
+	round := SwissRound{Number: len(tournament.Rounds) + 1, Byes: byes}
+	for _, players := range seating {
+		roomID, err := m.createTable(ctx, tournament, players, nil)
+		if err != nil {
+			return m.removeTables(ctx, round.Tables, err)
+		}
+		round.Tables = append(round.Tables, TournamentTable{RoomID: roomID, Players: players})
+	}
+	tournament.Rounds = append(tournament.Rounds, round)
+
+	return nil
+}
+
+// Collect settles the tables of the current round with the finished pulkas
+// and starts the next round once all of them are settled.
+func (m *TournamentManager) Collect(ctx context.Context, tournament *Tournament) error {
+	round := tournament.currentRound()
+	if tournament.Status != TournamentRunning || round == nil {
+		return nil
+	}
+
+	var settled []RoomID
+	finished := true
+	for i := range round.Tables {
+		table := &round.Tables[i]
+		if table.Finished {
+			continue
+		}
+
+		room, err := m.roomManager.GetOne(ctx, table.RoomID)
+		if errors.Is(err, mgo.ErrNotFound) {
+			// The players left the room, the host settles the table.
+			finished = false
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if room.Score == nil || !room.Score.Finished {
+			finished = false
+			continue
+		}
+
+		results := make([]PulkaResult, len(table.Players))
+		if !room.Score.Aborted {
+			sheet := room.Score.Results()
+			for j, name := range table.Players {
+				if k := room.Score.index(name); k != -1 {
+					results[j] = sheet[k]
+				}
+			}
+		}
+		table.settle(results)
+		settled = append(settled, room.ID)
+	}
+
+	if len(settled) > 0 {
+		err := m.dao.Update(ctx, tournament)
+		if errors.Is(err, errTournamentChanged) {
+			// Settled by someone else, the next collect goes on from there.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, roomID := range settled {
+			err := m.roomManager.dao.Remove(ctx, roomID)
+			if err != nil && !errors.Is(err, mgo.ErrNotFound) {
+				return err
+			}
+		}
+	}
+
+	if !finished {
+		return nil
+	}
+
+	if len(tournament.Rounds) >= tournament.RoundsCount || len(tournament.ActivePlayers()) < TournamentTableSize {
+		tournament.Status = TournamentFinished
+		return m.dao.Update(ctx, tournament)
+	}
+
+	if err := m.startRound(ctx, tournament); err != nil {
+		return err
+	}
+	if err := m.dao.Update(ctx, tournament); err != nil {
+		return m.removeTables(ctx, tournament.currentRound().Tables, err)
+	}
+
+	return nil
+}
+
+// NoShow is the host's override for the player who did not come to the
+// table of the current round: the table is settled with the player losing
+// to everybody, and the player is withdrawn from the next rounds. The next
+// round is started by the watcher.
+func (m *TournamentManager) NoShow(ctx context.Context, id TournamentID, hostName, playerName string) error {
+	tournament, err := m.getHosted(ctx, id, hostName)
+	if err != nil {
+		return err
+	}
+
+	round := tournament.currentRound()
+	if tournament.Status != TournamentRunning || round == nil {
+		return errors.New("no round is played")
+	}
+
+	for i := range round.Tables {
+		table := &round.Tables[i]
+		if !containsName(table.Players, playerName) {
+			continue
+		}
+		if table.Finished {
+			return errors.New("table is finished")
+		}
+
+		table.NoShows = append(table.NoShows, playerName)
+		table.settle(make([]PulkaResult, len(table.Players)))
+		tournament.Withdrawn = append(tournament.Withdrawn, playerName)
+		if err := m.dao.Update(ctx, tournament); err != nil {
+			return err
+		}
+
+		err := m.roomManager.dao.Remove(ctx, table.RoomID)
+		if errors.Is(err, mgo.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return errors.New("player is not seated")
+}
+
+func (m *TournamentManager) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.CollectRunning(ctx); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+// CollectRunning collects the running Swiss tournaments, a failure of one
+// does not hold up the others.
+func (m *TournamentManager) CollectRunning(ctx context.Context) error {
+	tournaments, err := m.dao.FindRunning(ctx, FormatSwiss)
+	if err != nil {
+		return err
+	}
+
+	for i := range tournaments {
+		if err := m.Collect(ctx, &tournaments[i]); err != nil {
+			log.Println(err)
+		}
+	}
+
+	return nil
+}
+
+// TournamentView has the cross-table report of the duplicate tournament or
+// the standings of the Swiss one.
+type TournamentView struct {
+	*Tournament
+	Report    *DuplicateReport `json:"report,omitempty"`
+	Standings []SwissStanding  `json:"standings,omitempty"`
+}
+
+func (m *TournamentManager) Get(ctx context.Context, id TournamentID) (*TournamentView, error) {
+	tournament, err := m.dao.FindOneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if tournament.Format == FormatSwiss {
+		return &TournamentView{
+			Tournament: tournament,
+			Standings:  SwissStandings(tournament.Players, tournament.Rounds),
+		}, nil
+	}
+
+	deals, err := m.roomManager.deals.FindByTournament(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	report := NewDuplicateReport(deals, tournament.Convention)
+
+	return &TournamentView{
+		Tournament: tournament,
+		Report:     &report,
+	}, nil
+}
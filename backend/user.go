@@ -2,8 +2,13 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"net/mail"
+	"strings"
+	"time"
 
 	"github.com/globalsign/mgo"
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,6 +20,11 @@ var (
 	UserCollectionName = "users"
 )
 
+const (
+	APIKeySize   = 24
+	APIKeyPrefix = "pref_"
+)
+
 type UserDAO struct {
 	collection *mgo.Collection
 }
@@ -42,6 +52,52 @@ func (d *UserDAO) FindOneByLogin(ctx context.Context, login string) (*User, erro
 	return &result, nil
 }
 
+func (d *UserDAO) FindOneByAPIKeyHash(ctx context.Context, hash string) (*User, error) {
+	var result User
+	if err := d.collection.Find(bson.M{"apiKeys": hash}).One(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (d *UserDAO) SetAPIKeyHashes(ctx context.Context, login string, hashes []string) error {
+	return d.collection.UpdateId(login, bson.M{
+		"$set": bson.M{
+			"apiKeys": hashes,
+		},
+	})
+}
+
+// AddRatingChange sets the new rating of the user and keeps the change in
+// the history.
+func (d *UserDAO) AddRatingChange(ctx context.Context, login string, change RatingChange) error {
+	return d.collection.UpdateId(login, bson.M{
+		"$set": bson.M{
+			"rating": change.After,
+		},
+		"$inc": bson.M{
+			"ratedPulkas": 1,
+		},
+		"$push": bson.M{
+			"ratingHistory": bson.M{
+				"$each":  []RatingChange{change},
+				"$slice": -MaxRatingHistory,
+			},
+		},
+	})
+}
+
+func (d *UserDAO) FindTopRated(ctx context.Context, limit int) ([]User, error) {
+	var result []User
+	query := bson.M{"ratedPulkas": bson.M{"$gte": ProvisionalPulkas}}
+	if err := d.collection.Find(query).Sort("-rating").Limit(limit).All(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (d *UserDAO) RemoveAll(ctx context.Context) error {
 	_, err := d.collection.RemoveAll(bson.M{})
 	return err
@@ -62,6 +118,10 @@ func (m *UserManager) Create(ctx context.Context, login, password, email string)
 		return errors.New("invalid email")
 	}
 
+	if strings.HasPrefix(login, BotNamePrefix) {
+		return errors.New("login is reserved for bots")
+	}
+
 	_, err := m.dao.FindOneByLogin(ctx, login)
 	if err == nil {
 		return errors.New("login already exist")
@@ -86,6 +146,157 @@ func (m *UserManager) Create(ctx context.Context, login, password, email string)
 	return m.dao.Insert(ctx, newUser)
 }
 
+func NewAPIKey() (string, string, error) {
+	raw := make([]byte, APIKeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	key := APIKeyPrefix + hex.EncodeToString(raw)
+	return key, APIKeyHash(key), nil
+}
+
+func APIKeyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateBot registers a bot account owned by the player and returns its
+// API key. The key is not stored, only its hash.
+func (m *UserManager) CreateBot(ctx context.Context, owner, login string) (string, error) {
+	if login == "" || strings.HasPrefix(login, BotNamePrefix) {
+		return "", errors.New("wrong login")
+	}
+
+	if u, err := m.dao.FindOneByLogin(ctx, owner); err == nil && u.Bot {
+		return "", errors.New("bots cannot create bots")
+	}
+
+	_, err := m.dao.FindOneByLogin(ctx, login)
+	if err == nil {
+		return "", errors.New("login already exist")
+	}
+
+	if err.Error() != "not found" {
+		return "", err
+	}
+
+	key, hash, err := NewAPIKey()
+	if err != nil {
+		return "", err
+	}
+
+	newUser := &User{
+		Login:        login,
+		Bot:          true,
+		Owner:        owner,
+		APIKeyHashes: []string{hash},
+	}
+
+	if err := m.dao.Insert(ctx, newUser); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// RotateAPIKey replaces all the keys of the owner's bot with a new one.
+func (m *UserManager) RotateAPIKey(ctx context.Context, owner, login string) (string, error) {
+	u, err := m.dao.FindOneByLogin(ctx, login)
+	if err != nil {
+		return "", err
+	}
+
+	if !u.Bot || u.Owner != owner {
+		return "", errors.New("not your bot")
+	}
+
+	key, hash, err := NewAPIKey()
+	if err != nil {
+		return "", err
+	}
+
+	if err := m.dao.SetAPIKeyHashes(ctx, login, []string{hash}); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (m *UserManager) CheckAPIKey(ctx context.Context, key string) (string, error) {
+	u, err := m.dao.FindOneByAPIKeyHash(ctx, APIKeyHash(key))
+	if err != nil {
+		if err.Error() == "not found" {
+			return "", errors.New("invalid api key")
+		}
+		return "", err
+	}
+
+	return u.Login, nil
+}
+
+// Rating of the user, the unknown users have the default one.
+func (m *UserManager) Rating(ctx context.Context, login string) (RatingEntry, error) {
+	u, err := m.dao.FindOneByLogin(ctx, login)
+	if errors.Is(err, mgo.ErrNotFound) {
+		return (&User{Login: login}).RatingEntry(), nil
+	}
+	if err != nil {
+		return RatingEntry{}, err
+	}
+
+	res := u.RatingEntry()
+	res.History = u.RatingHistory
+	return res, nil
+}
+
+// RecordPulka updates the ratings of the players after the rated pulka.
+func (m *UserManager) RecordPulka(ctx context.Context, roomID RoomID, score *ScoreSheet, now time.Time) error {
+	var players []RatingEntry
+	var ratings []float64
+	var pulkas []int
+	for _, p := range score.Players {
+		entry, err := m.Rating(ctx, p.Player)
+		if err != nil {
+			return err
+		}
+		players = append(players, entry)
+		ratings = append(ratings, entry.Rating)
+		pulkas = append(pulkas, entry.Pulkas)
+	}
+
+	deltas := RatingDeltas(ratings, score.Results(), pulkas)
+	for i, p := range players {
+		change := RatingChange{
+			Time:   now,
+			RoomID: roomID,
+			Before: p.Rating,
+			After:  p.Rating + deltas[i],
+		}
+		err := m.dao.AddRatingChange(ctx, p.Player, change)
+		if err != nil && !errors.Is(err, mgo.ErrNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Leaderboard lists the best players out of the provisional period.
+func (m *UserManager) Leaderboard(ctx context.Context) ([]RatingEntry, error) {
+	users, err := m.dao.FindTopRated(ctx, MaxLeaderboard)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []RatingEntry{}
+	for _, u := range users {
+		res = append(res, u.RatingEntry())
+	}
+
+	return res, nil
+}
+
 func (m *UserManager) Check(ctx context.Context, login, password string) error {
 	u, err := m.dao.FindOneByLogin(ctx, login)
 	if err != nil {
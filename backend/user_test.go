@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/globalsign/mgo"
 	"github.com/stretchr/testify/assert"
@@ -65,3 +66,59 @@ func (s *UserSuite) TestCreateWithInvalidMail() {
 	require.Error(s.T(), err)
 	assert.Contains(s.T(), err.Error(), "invalid email")
 }
+
+func (s *UserSuite) TestBotAPIKey() {
+	key, err := s.Manager.CreateBot(s.Ctx, "user5", "robot")
+	s.Require().NoError(err)
+
+	login, err := s.Manager.CheckAPIKey(s.Ctx, key)
+	s.Require().NoError(err)
+	s.Equal("robot", login)
+
+	s.Error(s.Manager.Check(s.Ctx, "robot", ""))
+	_, err = s.Manager.CreateBot(s.Ctx, "robot", "robot2")
+	s.Error(err)
+
+	_, err = s.Manager.RotateAPIKey(s.Ctx, "user6", "robot")
+	s.Require().Error(err)
+	s.Equal("not your bot", err.Error())
+
+	newKey, err := s.Manager.RotateAPIKey(s.Ctx, "user5", "robot")
+	s.Require().NoError(err)
+
+	_, err = s.Manager.CheckAPIKey(s.Ctx, key)
+	s.Error(err)
+	login, err = s.Manager.CheckAPIKey(s.Ctx, newKey)
+	s.Require().NoError(err)
+	s.Equal("robot", login)
+}
+
+func (s *UserSuite) TestRating() {
+	for _, login := range []string{"rated1", "rated2", "rated3"} {
+		s.Require().NoError(s.Manager.Create(s.Ctx, login, "pass", "some@mail.com"))
+	}
+
+	score := NewScoreSheet([]string{"rated1", "rated2", "rated3"}, ConventionSochi, 10)
+	score.Players[2].Mountain = 10
+	score.Finished = true
+	for i := 0; i < ProvisionalPulkas; i++ {
+		s.Require().NoError(s.Manager.RecordPulka(s.Ctx, RoomID{}, score, time.Now()))
+	}
+
+	entry, err := s.Manager.Rating(s.Ctx, "rated3")
+	s.Require().NoError(err)
+	s.False(entry.Provisional)
+	s.Less(entry.Rating, float64(DefaultRating))
+	s.Len(entry.History, ProvisionalPulkas)
+
+	leaderboard, err := s.Manager.Leaderboard(s.Ctx)
+	s.Require().NoError(err)
+	s.Require().Len(leaderboard, 3)
+	s.Equal("rated3", leaderboard[2].Player)
+	s.InDelta(leaderboard[0].Rating, leaderboard[1].Rating, 1e-9)
+
+	entry, err = s.Manager.Rating(s.Ctx, "newcomer")
+	s.Require().NoError(err)
+	s.True(entry.Provisional)
+	s.Equal(float64(DefaultRating), entry.Rating)
+}